@@ -2,15 +2,17 @@ package api
 
 import (
 	"errors"
+	"path/filepath"
+	"time"
 
 	"github.com/cosmos/cosmos-sdk/codec"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/gorilla/mux"
 	"github.com/tendermint/tendermint/libs/bytes"
-	rpcclient "github.com/tendermint/tendermint/rpc/client"
-	httprpcclient "github.com/tendermint/tendermint/rpc/client/http"
 	"github.com/terra-project/core/app"
 
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	authexported "github.com/cosmos/cosmos-sdk/x/auth/exported"
 	"github.com/terra-project/core/x/treasury"
 )
 
@@ -53,40 +55,240 @@ type Server struct {
 	KeyDir string `json:"key_dir"`
 	Node   string `json:"node"`
 
+	// ReadTimeout, WriteTimeout and IdleTimeout bound reading requests, writing responses and
+	// keeping idle connections open, no timeout applies when zero. WriteTimeout must be longer
+	// than BroadcastTimeout
+	ReadTimeout  time.Duration `json:"readtimeout"`
+	WriteTimeout time.Duration `json:"writetimeout"`
+	IdleTimeout  time.Duration `json:"idletimeout"`
+	// ShutdownTimeout bounds how long in-flight requests are drained on SIGINT or SIGTERM
+	ShutdownTimeout time.Duration `json:"shutdowntimeout"`
+
+	// ChainID is the chain the node must be on for /readyz, any chain will do without it
+	ChainID string `json:"chainid"`
+
+	// KeyringBackend is where keys are stored, one of legacy (default), file, os, test, memory or pkcs11
+	KeyringBackend string `json:"keyringbackend"`
+	// KeyringPassphrase encrypts the file keyring backend
+	KeyringPassphrase string `json:"-" yaml:"-"`
+
+	// PKCS11Library is the path of the PKCS#11 module of the pkcs11 keyring backend
+	PKCS11Library string `json:"pkcs11library"`
+	// PKCS11Slot is the slot of the token holding the keys
+	PKCS11Slot uint `json:"pkcs11slot"`
+	// PKCS11PIN is the user PIN of the token
+	PKCS11PIN string `json:"-" yaml:"-"`
+
+	// Tokens are the bearer tokens of the API, without tokens or client certs the API is open to anyone
+	Tokens []APIToken `json:"tokens"`
+	// ClientCerts grant scopes to the subjects of client certificates
+	ClientCerts []ClientCert `json:"clientcerts"`
+
+	// TLSCert and TLSKey are the PEM files of the certificate to serve HTTPS with
+	TLSCert string `json:"tlscert"`
+	TLSKey  string `json:"tlskey"`
+	// TLSClientCA is the PEM file of the CA verifying client certificates
+	TLSClientCA string `json:"tlsclientca"`
+	// TLSRequireClientCert rejects connections without a client certificate of TLSClientCA
+	TLSRequireClientCert bool `json:"tlsrequireclientcert"`
+
+	// Nodes are more nodes to fail over to when Node can't be reached
+	Nodes []string `json:"nodes"`
+	// RPCTimeout bounds every rpc request to a node
+	RPCTimeout time.Duration `json:"rpctimeout"`
+	// HealthCheckInterval is how often the health of the nodes is checked
+	HealthCheckInterval time.Duration `json:"healthcheckinterval"`
+
+	// TreasuryTTL is how long the tax rate and tax caps are cached within a treasury epoch
+	TreasuryTTL time.Duration `json:"treasuryttl"`
+
+	// Policies restrict what the keyserver signs with each key
+	Policies []SigningPolicy `json:"policies"`
+
+	// SessionMaxTTL bounds how long a key stays unlocked for /keys/{name}/unlock
+	SessionMaxTTL time.Duration `json:"sessionmaxttl"`
+
+	// AuditLog is the file of the hash chained log of key and signing operations, no log is kept without it
+	AuditLog string `json:"auditlog"`
+
+	// BroadcastTimeout bounds how long block and commit-wait broadcasts wait for block inclusion
+	BroadcastTimeout time.Duration `json:"broadcasttimeout"`
+
 	Version string `yaml:"version,omitempty"`
 	Commit  string `yaml:"commit,omitempty"`
 	Branch  string `yaml:"branch,omitempty"`
+
+	sequences *sequenceManager
+	prevotes  *prevoteStore
+	nodes     *nodePool
+	treasury  *treasuryCache
+	keybase   Keybase
+	sessions  *sessionStore
+	auth      *authenticator
+	tls       *tlsReloader
+	policies  *policyEngine
+	audit     *auditLog
+	metrics   *metrics
+}
+
+// remotes returns the addresses of the configured nodes, Node first
+func (s *Server) remotes() (remotes []string) {
+	seen := make(map[string]bool)
+	for _, remote := range append([]string{s.Node}, s.Nodes...) {
+		if remote == "" || seen[remote] {
+			continue
+		}
+		seen[remote] = true
+		remotes = append(remotes, remote)
+	}
+
+	return remotes
 }
 
 // Router returns the router
 func (s *Server) Router() *mux.Router {
+	// OpenKeybase is expected to be called before, open the keybase here for servers that didn't
+	if s.keybase == nil {
+		if err := s.OpenKeybase(); err != nil {
+			panic(err)
+		}
+	}
+
+	if s.sequences == nil {
+		s.sequences = newSequenceManager()
+	}
+
+	if s.prevotes == nil {
+		var path string
+		if s.KeyDir != "" {
+			path = filepath.Join(s.KeyDir, "oracle-prevotes.json")
+		}
+
+		prevotes, err := newPrevoteStore(path)
+		if err != nil {
+			panic(err)
+		}
+		s.prevotes = prevotes
+	}
+
+	if s.nodes == nil {
+		s.nodes = newNodePool(s.remotes(), s.RPCTimeout, s.BroadcastWait())
+
+		// a single node has nothing to fail over to
+		if len(s.nodes.nodes) > 1 {
+			s.nodes.start(s.HealthCheckInterval)
+		}
+	}
+
+	if s.sessions == nil {
+		s.sessions = newSessionStore()
+	}
+
+	if s.treasury == nil {
+		s.treasury = newTreasuryCache(s.TreasuryTTL)
+	}
+
+	if s.auth == nil {
+		auth, err := newAuthenticator(s.Tokens, s.ClientCerts)
+		if err != nil {
+			panic(err)
+		}
+		s.auth = auth
+	}
+
+	if s.policies == nil {
+		var path string
+		if s.KeyDir != "" {
+			path = filepath.Join(s.KeyDir, "policy-spends.json")
+		}
+
+		policies, err := newPolicyEngine(s.Policies, path)
+		if err != nil {
+			panic(err)
+		}
+		s.policies = policies
+	}
+
+	if s.audit == nil && s.AuditLog != "" {
+		audit, err := openAuditLog(s.AuditLog)
+		if err != nil {
+			panic(err)
+		}
+		s.audit = audit
+	}
+
 	router := mux.NewRouter()
+	router.Use(s.Instrument, s.Audit, s.Authenticate)
 
 	router.HandleFunc("/version", s.VersionHandler).Methods("GET")
+	router.HandleFunc("/metrics", s.Metrics).Methods("GET")
+	router.HandleFunc("/healthz", s.Healthz).Methods("GET")
+	router.HandleFunc("/readyz", s.Readyz).Methods("GET")
+	router.HandleFunc("/treasury", s.Treasury).Methods("GET")
 	router.HandleFunc("/keys", s.GetKeys).Methods("GET")
 	router.HandleFunc("/keys", s.PostKeys).Methods("POST")
 	router.HandleFunc("/keys/{name}", s.GetKey).Methods("GET")
 	router.HandleFunc("/keys/{name}", s.PutKey).Methods("PUT")
 	router.HandleFunc("/keys/{name}", s.DeleteKey).Methods("DELETE")
+	router.HandleFunc("/keys/{name}/unlock", s.Unlock).Methods("POST")
+	router.HandleFunc("/keys/{name}/lock", s.Lock).Methods("POST")
 	router.HandleFunc("/tx/sign", s.Sign).Methods("POST")
 	router.HandleFunc("/tx/broadcast", s.Broadcast).Methods("POST")
+	router.HandleFunc("/tx/submit", s.Submit).Methods("POST")
+	router.HandleFunc("/tx/compose", s.Compose).Methods("POST")
+	router.HandleFunc("/tx/estimate", s.Estimate).Methods("POST")
 	router.HandleFunc("/tx/bank/send", s.BankSend).Methods("POST")
+	router.HandleFunc("/tx/staking/delegate", s.Delegate).Methods("POST")
+	router.HandleFunc("/tx/staking/undelegate", s.Undelegate).Methods("POST")
+	router.HandleFunc("/tx/staking/redelegate", s.Redelegate).Methods("POST")
+	router.HandleFunc("/tx/distribution/withdraw", s.WithdrawRewards).Methods("POST")
+	router.HandleFunc("/tx/market/swap", s.Swap).Methods("POST")
+	router.HandleFunc("/tx/gov/vote", s.GovVote).Methods("POST")
+	router.HandleFunc("/tx/gov/deposit", s.GovDeposit).Methods("POST")
+	router.HandleFunc("/tx/gov/proposal", s.GovProposal).Methods("POST")
+	router.HandleFunc("/tx/wasm/store", s.WasmStore).Methods("POST")
+	router.HandleFunc("/tx/wasm/instantiate", s.WasmInstantiate).Methods("POST")
+	router.HandleFunc("/tx/wasm/execute", s.WasmExecute).Methods("POST")
+	router.HandleFunc("/tx/oracle/prevote", s.OraclePrevote).Methods("POST")
+	router.HandleFunc("/tx/oracle/vote", s.OracleVote).Methods("POST")
 	router.HandleFunc("/tx/encode", s.EncodeTx).Methods("POST")
+	router.HandleFunc("/tx/{hash}", s.GetTx).Methods("GET")
 
 	return router
 }
 
+// Close closes the keybase, the audit log and the connections to the nodes and stops the
+// node health checks
+func (s *Server) Close() error {
+	if s.nodes != nil {
+		s.nodes.stop()
+	}
+
+	if s.audit != nil {
+		s.audit.close()
+	}
+
+	if s.sessions != nil {
+		s.sessions.lockAll()
+	}
+
+	if s.keybase != nil {
+		return s.keybase.Close()
+	}
+
+	return nil
+}
+
 // SimulateGas simulates gas for a transaction
 func (s *Server) SimulateGas(txbytes []byte) (res uint64, err error) {
-	client, err := httprpcclient.New(s.Node, "/websocket")
-	if err != nil {
-		return
-	}
+	defer func(start time.Time) {
+		s.instruments().simulateGas.Observe(time.Since(start).Seconds())
+		s.observeRPC("simulate", err)
+	}(time.Now())
 
-	result, err := client.ABCIQueryWithOptions(
+	result, err := s.queryABCI(
 		"/app/simulate",
 		bytes.HexBytes(txbytes),
-		rpcclient.ABCIQueryOptions{},
 	)
 
 	if err != nil {
@@ -107,15 +309,11 @@ func (s *Server) SimulateGas(txbytes []byte) (res uint64, err error) {
 
 // LoadTaxRate load tax-rate
 func (s *Server) LoadTaxRate() (res sdk.Dec, err error) {
-	client, err := httprpcclient.New(s.Node, "/websocket")
-	if err != nil {
-		return
-	}
+	defer func() { s.observeRPC("tax_rate", err) }()
 
-	result, err := client.ABCIQueryWithOptions(
+	result, err := s.queryABCI(
 		"custom/treasury/taxRate",
 		[]byte{},
-		rpcclient.ABCIQueryOptions{},
 	)
 	if err != nil {
 		return
@@ -135,10 +333,7 @@ func (s *Server) LoadTaxRate() (res sdk.Dec, err error) {
 
 // LoadTaxCap load tax-cap
 func (s *Server) LoadTaxCap(denom string) (res sdk.Int, err error) {
-	client, err := httprpcclient.New(s.Node, "/websocket")
-	if err != nil {
-		return
-	}
+	defer func() { s.observeRPC("tax_cap", err) }()
 
 	params := treasury.NewQueryTaxCapParams(denom)
 	bz, err := cdc.MarshalJSON(params)
@@ -146,10 +341,9 @@ func (s *Server) LoadTaxCap(denom string) (res sdk.Int, err error) {
 		return sdk.ZeroInt(), err
 	}
 
-	result, err := client.ABCIQueryWithOptions(
+	result, err := s.queryABCI(
 		"custom/treasury/taxCap",
 		bytes.HexBytes(bz),
-		rpcclient.ABCIQueryOptions{},
 	)
 	if err != nil {
 		return
@@ -166,3 +360,35 @@ func (s *Server) LoadTaxCap(denom string) (res sdk.Int, err error) {
 
 	return taxRate, nil
 }
+
+// LoadAccount load account
+func (s *Server) LoadAccount(address sdk.AccAddress) (res authexported.Account, err error) {
+	defer func() { s.observeRPC("account", err) }()
+
+	bz, err := cdc.MarshalJSON(auth.NewQueryAccountParams(address))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.queryABCI(
+		"custom/acc/account",
+		bytes.HexBytes(bz),
+	)
+	if err != nil {
+		if isNodeFailure(err) {
+			err = unreachableError{err}
+		}
+		return
+	}
+
+	if !result.Response.IsOK() {
+		return nil, errors.New(result.Response.Log)
+	}
+
+	var account authexported.Account
+	if err := cdc.UnmarshalJSON(result.Response.Value, &account); err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
@@ -7,10 +7,16 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
 	ckeys "github.com/cosmos/cosmos-sdk/crypto/keys"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/bank"
 	"github.com/stretchr/testify/require"
+
+	"github.com/terra-project/core/x/gov"
 )
 
 const (
@@ -87,6 +93,219 @@ func TestGetKeys(t *testing.T) {
 	require.Empty(t, happyPath)
 }
 
+func TestSign(t *testing.T) {
+	server := setup(t)
+	defer server.Close()
+
+	addNP := AddNewKey{Name: testKey, Password: testPass, Mnemonic: sMenominc}
+	postRoute(t, fmt.Sprintf("%s/keys", server.URL), addNP.Marshal(), 200)
+
+	sender, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+
+	coins := sdk.NewCoins(sdk.NewInt64Coin("uluna", 1000))
+	unsignedTx := auth.NewStdTx(
+		[]sdk.Msg{bank.NewMsgSend(sender, sender, coins)},
+		auth.NewStdFee(200000, coins),
+		[]auth.StdSignature{},
+		"",
+	)
+
+	// test sign w/ explicit account number and sequence
+	signBody := SignBody{
+		Tx:            cdc.MustMarshalJSON(unsignedTx),
+		Name:          testKey,
+		Passphrase:    testPass,
+		ChainID:       "testing",
+		AccountNumber: "3",
+		Sequence:      "7",
+	}
+	resp, err := http.Post(fmt.Sprintf("%s/tx/sign", server.URL), "application/json", bytes.NewBuffer(signBody.Marshal()))
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	require.Equal(t, "3", resp.Header.Get(HeaderAccountNumber))
+	require.Equal(t, "7", resp.Header.Get(HeaderSequence))
+
+	out, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var signedTx auth.StdTx
+	require.NoError(t, cdc.UnmarshalJSON(out, &signedTx))
+	require.Len(t, signedTx.Signatures, 1)
+
+	// test sign w/o account number fails when the node is unreachable
+	signBody.AccountNumber = ""
+	postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), signBody.Marshal(), 502)
+}
+
+func TestSignUnknownAccount(t *testing.T) {
+	node := fakeNode(`{"response":{"code":9,"log":"account terra1... does not exist"}}`, nil)
+	defer node.Close()
+
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	s := &Server{KeyDir: dir, Node: node.URL}
+	server := httptest.NewServer(s.Router())
+	defer server.Close()
+
+	addNP := AddNewKey{Name: testKey, Password: testPass, Mnemonic: sMenominc}
+	postRoute(t, fmt.Sprintf("%s/keys", server.URL), addNP.Marshal(), 200)
+
+	sender, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+
+	coins := sdk.NewCoins(sdk.NewInt64Coin("uluna", 1000))
+	unsignedTx := auth.NewStdTx(
+		[]sdk.Msg{bank.NewMsgSend(sender, sender, coins)},
+		auth.NewStdFee(200000, coins),
+		[]auth.StdSignature{},
+		"",
+	)
+
+	// the node answers, the account isn't on chain
+	signBody := SignBody{
+		Tx:         cdc.MustMarshalJSON(unsignedTx),
+		Name:       testKey,
+		Passphrase: testPass,
+		ChainID:    "testing",
+	}
+	postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), signBody.Marshal(), 400)
+}
+
+func TestParallelSign(t *testing.T) {
+	server := setup(t)
+	defer server.Close()
+
+	addNP := AddNewKey{Name: testKey, Password: testPass, Mnemonic: sMenominc}
+	postRoute(t, fmt.Sprintf("%s/keys", server.URL), addNP.Marshal(), 200)
+
+	sender, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+
+	coins := sdk.NewCoins(sdk.NewInt64Coin("uluna", 1000))
+	unsignedTx := auth.NewStdTx([]sdk.Msg{bank.NewMsgSend(sender, sender, coins)}, auth.NewStdFee(200000, coins), nil, "")
+
+	// test concurrent requests don't fail on the keybase lock
+	var wg sync.WaitGroup
+	statuses := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			signBody := SignBody{
+				Tx:            cdc.MustMarshalJSON(unsignedTx),
+				Name:          testKey,
+				Passphrase:    testPass,
+				ChainID:       "testing",
+				AccountNumber: "3",
+				Sequence:      fmt.Sprint(i),
+			}
+			resp, err := http.Post(fmt.Sprintf("%s/tx/sign", server.URL), "application/json", bytes.NewBuffer(signBody.Marshal()))
+			if err != nil {
+				statuses <- 0
+				return
+			}
+			statuses <- resp.StatusCode
+
+			// reads share the keybase with signing
+			if resp, err := http.Get(fmt.Sprintf("%s/keys/%s", server.URL, testKey)); err == nil {
+				resp.Body.Close()
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(statuses)
+
+	for status := range statuses {
+		require.Equal(t, 200, status)
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	server := setup(t)
+	defer server.Close()
+
+	// test submit requires exactly one of tx and send
+	submitBody := SubmitBody{Name: testKey, Passphrase: testPass, ChainID: "testing"}
+	missing := unmarshalError(postRoute(t, fmt.Sprintf("%s/tx/submit", server.URL), submitBody.Marshal(), 400))
+	require.NotEmpty(t, missing.Error)
+
+	submitBody.Tx = []byte("{}")
+	submitBody.Send = &BankSendBody{Amount: "1000uluna"}
+	both := unmarshalError(postRoute(t, fmt.Sprintf("%s/tx/submit", server.URL), submitBody.Marshal(), 400))
+	require.Equal(t, missing.Error, both.Error)
+}
+
+func TestGovVote(t *testing.T) {
+	server := setup(t)
+	defer server.Close()
+
+	voter, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+
+	vb := GovVoteBody{Voter: voter, ProposalID: "3", Option: "no_with_veto", ChainID: "testing", FeeOptions: FeeOptions{Fees: "1000uluna", Gas: "200000"}}
+	var stdTx auth.StdTx
+	require.NoError(t, cdc.UnmarshalJSON(postRoute(t, fmt.Sprintf("%s/tx/gov/vote", server.URL), vb.Marshal(), 200), &stdTx))
+	require.Equal(t, gov.NewMsgVote(voter, 3, gov.OptionNoWithVeto), stdTx.Msgs[0])
+
+	// test invalid option
+	vb.Option = "maybe"
+	postRoute(t, fmt.Sprintf("%s/tx/gov/vote", server.URL), vb.Marshal(), 400)
+}
+
+func TestCompose(t *testing.T) {
+	server := setup(t)
+	defer server.Close()
+
+	sender, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+
+	msgs := []sdk.Msg{
+		gov.NewMsgVote(sender, 3, gov.OptionYes),
+		gov.NewMsgDeposit(sender, 3, sdk.NewCoins(sdk.NewInt64Coin("uluna", 100))),
+	}
+	cb := ComposeBody{Msgs: cdc.MustMarshalJSON(msgs), ChainID: "testing", Memo: "bundle", FeeOptions: FeeOptions{Fees: "1000uluna", Gas: "300000"}}
+	var stdTx auth.StdTx
+	require.NoError(t, cdc.UnmarshalJSON(postRoute(t, fmt.Sprintf("%s/tx/compose", server.URL), cb.Marshal(), 200), &stdTx))
+	require.Equal(t, msgs, stdTx.Msgs)
+	require.Equal(t, "bundle", stdTx.Memo)
+	require.Equal(t, uint64(300000), stdTx.Fee.Gas)
+
+	// test empty and invalid msgs
+	cb.Msgs = []byte("[]")
+	postRoute(t, fmt.Sprintf("%s/tx/compose", server.URL), cb.Marshal(), 400)
+	cb.Msgs = cdc.MustMarshalJSON([]sdk.Msg{gov.NewMsgVote(nil, 3, gov.OptionYes)})
+	postRoute(t, fmt.Sprintf("%s/tx/compose", server.URL), cb.Marshal(), 400)
+}
+
+func TestEstimate(t *testing.T) {
+	server := setup(t)
+	defer server.Close()
+
+	voter, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+
+	msgs := []sdk.Msg{gov.NewMsgVote(voter, 3, gov.OptionYes)}
+	eb := EstimateBody{Msgs: cdc.MustMarshalJSON(msgs), FeeOptions: FeeOptions{Gas: "100000", GasPrices: "0.015uluna", GasAdjustment: "1.5"}}
+	var estimate FeeEstimate
+	require.NoError(t, cdc.UnmarshalJSON(postRoute(t, fmt.Sprintf("%s/tx/estimate", server.URL), eb.Marshal(), 200), &estimate))
+	require.Equal(t, uint64(150000), estimate.Gas)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("uluna", 2250)), estimate.GasFee)
+	require.True(t, estimate.Tax.Empty())
+	require.Equal(t, estimate.GasFee, estimate.Fee)
+
+	// test estimating an unsigned tx
+	eb.Msgs = nil
+	eb.Tx = cdc.MustMarshalJSON(auth.NewStdTx(msgs, auth.NewStdFee(0, nil), nil, "memo"))
+	require.NoError(t, cdc.UnmarshalJSON(postRoute(t, fmt.Sprintf("%s/tx/estimate", server.URL), eb.Marshal(), 200), &estimate))
+	require.Equal(t, uint64(150000), estimate.Gas)
+
+	// test both tx and msgs
+	eb.Msgs = cdc.MustMarshalJSON(msgs)
+	postRoute(t, fmt.Sprintf("%s/tx/estimate", server.URL), eb.Marshal(), 400)
+}
+
 func unmarshalError(in []byte) (out restError) {
 	err := json.Unmarshal(in, &out)
 	if err != nil {
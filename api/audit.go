@@ -0,0 +1,288 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Audit actions, the key and signing operations recorded in the audit log
+const (
+	AuditCreateKey = "keys/create"
+	AuditUpdateKey = "keys/update"
+	AuditDeleteKey = "keys/delete"
+	AuditUnlockKey = "keys/unlock"
+	AuditLockKey   = "keys/lock"
+	AuditSign      = "tx/sign"
+	AuditBroadcast = "tx/broadcast"
+	AuditSubmit    = "tx/submit"
+)
+
+// AuditEntry is a line of the audit log. Hash is the sha256 of the entry without its hash,
+// and the entry holds the hash of the previous one, so changing, dropping or reordering
+// entries breaks the chain.
+type AuditEntry struct {
+	Seq    uint64    `json:"seq"`
+	Time   time.Time `json:"time"`
+	Caller string    `json:"caller,omitempty"`
+	Remote string    `json:"remote"`
+	Action string    `json:"action"`
+	Key    string    `json:"key,omitempty"`
+	// SignBytesHash is the hex sha256 of the bytes signed
+	SignBytesHash string `json:"sign_bytes_hash,omitempty"`
+	TxHash        string `json:"tx_hash,omitempty"`
+	// Msgs are the amino names of the msgs of the transaction
+	Msgs     []string `json:"msgs,omitempty"`
+	Status   int      `json:"status"`
+	Error    string   `json:"error,omitempty"`
+	PrevHash string   `json:"prev_hash"`
+	Hash     string   `json:"hash"`
+}
+
+// hash returns the hash of the entry without its hash
+func (e AuditEntry) hash() (string, error) {
+	e.Hash = ""
+	bz, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(bz)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// auditLog appends entries to the audit log file
+type auditLog struct {
+	mtx  sync.Mutex
+	file *os.File
+	seq  uint64
+	last string
+}
+
+// openAuditLog opens the audit log at path for appending, after verifying the chain of the
+// entries already in it
+func openAuditLog(path string) (*auditLog, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the audit log: %s", err.Error())
+	}
+
+	seq, last, err := VerifyAuditLog(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("audit log %s doesn't verify: %s", path, err.Error())
+	}
+
+	return &auditLog{file: file, seq: seq, last: last}, nil
+}
+
+// record chains the entry to the last one and writes it to disk
+func (al *auditLog) record(entry *AuditEntry) error {
+	al.mtx.Lock()
+	defer al.mtx.Unlock()
+
+	entry.Seq = al.seq + 1
+	entry.Time = time.Now().UTC()
+	entry.PrevHash = al.last
+
+	hash, err := entry.hash()
+	if err != nil {
+		return err
+	}
+	entry.Hash = hash
+
+	bz, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if _, err := al.file.Write(append(bz, '\n')); err != nil {
+		return fmt.Errorf("failed to write the audit log: %s", err.Error())
+	}
+
+	if err := al.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync the audit log: %s", err.Error())
+	}
+
+	al.seq, al.last = entry.Seq, entry.Hash
+	return nil
+}
+
+func (al *auditLog) close() error {
+	al.mtx.Lock()
+	defer al.mtx.Unlock()
+
+	return al.file.Close()
+}
+
+// VerifyAuditLog checks the hash chain of the audit log, it returns the number of entries
+// and the hash of the last one. Entries cut off the end of the log can only be told by
+// comparing the last hash with one noted before.
+func VerifyAuditLog(r io.Reader) (entries uint64, last string, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		var entry AuditEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return entries, last, fmt.Errorf("entry %d: %s", entries+1, err.Error())
+		}
+
+		if entry.Seq != entries+1 {
+			return entries, last, fmt.Errorf("entry %d has sequence %d", entries+1, entry.Seq)
+		}
+
+		if entry.PrevHash != last {
+			return entries, last, fmt.Errorf("entry %d doesn't chain to the previous entry", entry.Seq)
+		}
+
+		hash, err := entry.hash()
+		if err != nil {
+			return entries, last, err
+		}
+
+		// a field added to the line doesn't survive the round trip
+		bz, err := json.Marshal(entry)
+		if err != nil {
+			return entries, last, err
+		}
+
+		if entry.Hash != hash || !bytes.Equal(bz, line) {
+			return entries, last, fmt.Errorf("entry %d was altered", entry.Seq)
+		}
+
+		entries, last = entry.Seq, entry.Hash
+	}
+
+	if err := scanner.Err(); err != nil {
+		return entries, last, err
+	}
+
+	return entries, last, nil
+}
+
+type auditContextKey struct{}
+
+// auditEntry returns the audit entry of the request for the handlers to fill in, nil when
+// the request isn't audited
+func auditEntry(r *http.Request) *AuditEntry {
+	entry, _ := r.Context().Value(auditContextKey{}).(*AuditEntry)
+	return entry
+}
+
+// auditAction returns the audit action of the route of the request, empty for routes that
+// aren't audited
+func auditAction(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+
+	switch template {
+	case "/keys":
+		if r.Method == http.MethodPost {
+			return AuditCreateKey
+		}
+	case "/keys/{name}":
+		switch r.Method {
+		case http.MethodPut:
+			return AuditUpdateKey
+		case http.MethodDelete:
+			return AuditDeleteKey
+		}
+	case "/keys/{name}/unlock":
+		return AuditUnlockKey
+	case "/keys/{name}/lock":
+		return AuditLockKey
+	case "/tx/sign":
+		return AuditSign
+	case "/tx/broadcast":
+		return AuditBroadcast
+	case "/tx/submit":
+		return AuditSubmit
+	}
+
+	return ""
+}
+
+// auditWriter holds the response back until its audit entry is written
+type auditWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (aw *auditWriter) Header() http.Header {
+	return aw.header
+}
+
+func (aw *auditWriter) Write(bz []byte) (int, error) {
+	if aw.status == 0 {
+		aw.status = http.StatusOK
+	}
+	return aw.body.Write(bz)
+}
+
+func (aw *auditWriter) WriteHeader(status int) {
+	if aw.status == 0 {
+		aw.status = status
+	}
+}
+
+// Audit is the middleware recording the key and signing operations in the audit log, including
+// the ones refused. The response is only sent once its entry is on disk, so no key is
+// handed out without a record.
+func (s *Server) Audit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action := auditAction(r)
+		if s.audit == nil || action == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		entry := &AuditEntry{Remote: r.RemoteAddr, Action: action, Key: mux.Vars(r)["name"]}
+		aw := &auditWriter{header: make(http.Header)}
+		next.ServeHTTP(aw, r.WithContext(context.WithValue(r.Context(), auditContextKey{}, entry)))
+
+		if aw.status == 0 {
+			aw.status = http.StatusOK
+		}
+		entry.Status = aw.status
+		if aw.status >= http.StatusBadRequest {
+			var restErr restError
+			if err := json.Unmarshal(aw.body.Bytes(), &restErr); err == nil {
+				entry.Error = restErr.Error
+			}
+		}
+
+		if err := s.audit.record(entry); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write(newError(err).marshal())
+			return
+		}
+
+		for key, values := range aw.header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(aw.status)
+		w.Write(aw.body.Bytes())
+	})
+}
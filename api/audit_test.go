@@ -0,0 +1,159 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/stretchr/testify/require"
+)
+
+// readAuditLog returns the entries of the audit log
+func readAuditLog(t *testing.T, path string) (entries []AuditEntry) {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+	return entries
+}
+
+func TestAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	s := &Server{
+		KeyringBackend: KeyringBackendMemory,
+		AuditLog:       path,
+		Tokens:         []APIToken{{Name: "admin", Token: "admintoken", Scopes: []string{ScopeAll}}},
+	}
+	server := httptest.NewServer(s.Router())
+	defer server.Close()
+
+	addNP := AddNewKey{Name: testKey, Password: testPass, Mnemonic: sMenominc}
+	authRoute(t, http.MethodPost, fmt.Sprintf("%s/keys", server.URL), "admintoken", addNP.Marshal(), 200)
+	authRoute(t, http.MethodGet, fmt.Sprintf("%s/keys", server.URL), "admintoken", nil, 200)
+
+	sender, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+	coins := sdk.NewCoins(sdk.NewInt64Coin("uluna", 1000))
+	unsignedTx := auth.NewStdTx([]sdk.Msg{bank.NewMsgSend(sender, sender, coins)}, auth.NewStdFee(200000, coins), nil, "")
+
+	signBody := SignBody{Tx: cdc.MustMarshalJSON(unsignedTx), Name: testKey, Passphrase: testPass, ChainID: "testing", AccountNumber: "3", Sequence: "7"}
+	authRoute(t, http.MethodPost, fmt.Sprintf("%s/tx/sign", server.URL), "admintoken", signBody.Marshal(), 200)
+	authRoute(t, http.MethodPost, fmt.Sprintf("%s/tx/sign", server.URL), "", signBody.Marshal(), 401)
+	signBody.Passphrase = testPassAlt
+	authRoute(t, http.MethodPost, fmt.Sprintf("%s/tx/sign", server.URL), "admintoken", signBody.Marshal(), 500)
+
+	deleteBody := DeleteKeyBody{Password: testPass}
+	authRoute(t, http.MethodDelete, fmt.Sprintf("%s/keys/%s", server.URL, testKey), "admintoken", deleteBody.Marshal(), 200)
+
+	// test every key and signing operation is recorded, reads aren't
+	entries := readAuditLog(t, path)
+	require.Len(t, entries, 5)
+
+	require.Equal(t, AuditCreateKey, entries[0].Action)
+	require.Equal(t, testKey, entries[0].Key)
+	require.Equal(t, "admin", entries[0].Caller)
+	require.Equal(t, 200, entries[0].Status)
+
+	stdSign, _, err := signBody.StdSignMsg()
+	require.NoError(t, err)
+	signBytes := sdk.MustSortJSON(cdc.MustMarshalJSON(stdSign))
+	require.Equal(t, AuditSign, entries[1].Action)
+	require.Equal(t, testKey, entries[1].Key)
+	require.Equal(t, []string{"bank/MsgSend"}, entries[1].Msgs)
+	sum := sha256.Sum256(signBytes)
+	require.Equal(t, hex.EncodeToString(sum[:]), entries[1].SignBytesHash)
+	require.Equal(t, entries[1].SignBytesHash, entries[3].SignBytesHash)
+
+	require.Empty(t, entries[2].Caller)
+	require.Equal(t, 401, entries[2].Status)
+	require.NotEmpty(t, entries[2].Error)
+	require.Equal(t, 500, entries[3].Status)
+	require.Equal(t, AuditDeleteKey, entries[4].Action)
+
+	for i, entry := range entries {
+		require.Equal(t, uint64(i+1), entry.Seq)
+		if i > 0 {
+			require.Equal(t, entries[i-1].Hash, entry.PrevHash)
+		}
+	}
+
+	// test a restarted server continues the chain
+	server.Close()
+	require.NoError(t, s.Close())
+	s = &Server{KeyringBackend: KeyringBackendMemory, AuditLog: path}
+	server = httptest.NewServer(s.Router())
+	defer server.Close()
+	defer s.Close()
+	postRoute(t, fmt.Sprintf("%s/keys", server.URL), addNP.Marshal(), 200)
+	entries = readAuditLog(t, path)
+	require.Len(t, entries, 6)
+	require.Equal(t, entries[4].Hash, entries[5].PrevHash)
+
+	bz, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	n, last, err := VerifyAuditLog(bytes.NewReader(bz))
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), n)
+	require.Equal(t, entries[5].Hash, last)
+}
+
+func TestVerifyAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	al, err := openAuditLog(path)
+	require.NoError(t, err)
+	for _, key := range []string{"jack", "jim", "yun"} {
+		require.NoError(t, al.record(&AuditEntry{Action: AuditCreateKey, Key: key, Status: 200}))
+	}
+	require.NoError(t, al.close())
+
+	bz, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.SplitAfter(strings.TrimSuffix(string(bz), "\n"), "\n")
+	require.Len(t, lines, 3)
+
+	verify := func(lines ...string) error {
+		_, _, err := VerifyAuditLog(strings.NewReader(strings.Join(lines, "")))
+		return err
+	}
+	require.NoError(t, verify(lines...))
+
+	// test altered, added, dropped and reordered entries break the chain
+	require.Error(t, verify(lines[0], strings.Replace(lines[1], `"jim"`, `"joe"`, 1), lines[2]))
+	require.Error(t, verify(lines[0], strings.Replace(lines[1], `"status":200`, `"status":200,"note":"x"`, 1), lines[2]))
+	require.Error(t, verify(lines[0], lines[2]))
+	require.Error(t, verify(lines[1], lines[0], lines[2]))
+	require.Error(t, verify(lines[0], lines[1], lines[1], lines[2]))
+
+	// test a broken log isn't appended to
+	require.NoError(t, ioutil.WriteFile(path, []byte(lines[0]+lines[2]), 0600))
+	_, err = openAuditLog(path)
+	require.Error(t, err)
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// API token scopes
+const (
+	// ScopeAll grants every scope
+	ScopeAll = "*"
+	// ScopeKeysRead allows listing keys and reading their public keys
+	ScopeKeysRead = "keys:read"
+	// ScopeKeysWrite allows creating, updating and deleting keys
+	ScopeKeysWrite = "keys:write"
+	// ScopeTx allows building, estimating, encoding, broadcasting and querying transactions
+	ScopeTx = "tx"
+	// ScopeSignPrefix followed by a key name allows signing with the key, sign:* with every key
+	ScopeSignPrefix = "sign:"
+)
+
+// APIToken is a bearer token that grants its scopes on the API
+type APIToken struct {
+	Name   string   `json:"name"`
+	Token  string   `json:"token"`
+	Scopes []string `json:"scopes"`
+}
+
+// allows returns whether the token grants scope
+func (t APIToken) allows(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == ScopeAll || s == scope {
+			return true
+		}
+		if s == ScopeSignPrefix+"*" && strings.HasPrefix(scope, ScopeSignPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticator checks the client certificates and bearer tokens of requests. It knows the
+// tokens by their sha256 so comparing them takes the same time for every token.
+type authenticator struct {
+	tokens map[[sha256.Size]byte]APIToken
+	certs  map[string]APIToken
+}
+
+func newAuthenticator(tokens []APIToken, certs []ClientCert) (*authenticator, error) {
+	a := &authenticator{tokens: make(map[[sha256.Size]byte]APIToken), certs: make(map[string]APIToken)}
+	for _, cert := range certs {
+		if cert.Subject == "" {
+			return nil, fmt.Errorf("client cert without subject")
+		}
+		if _, ok := a.certs[cert.Subject]; ok {
+			return nil, fmt.Errorf("client cert %s is configured twice", cert.Subject)
+		}
+		a.certs[cert.Subject] = APIToken{Name: cert.Subject, Scopes: cert.Scopes}
+	}
+
+	for _, token := range tokens {
+		if token.Token == "" {
+			return nil, fmt.Errorf("api token %s has no token", token.Name)
+		}
+
+		hash := sha256.Sum256([]byte(token.Token))
+		if _, ok := a.tokens[hash]; ok {
+			return nil, fmt.Errorf("api token %s is configured twice", token.Name)
+		}
+		a.tokens[hash] = token
+	}
+
+	return a, nil
+}
+
+// enabled returns whether any token or client cert is configured, without them the API is open
+func (a *authenticator) enabled() bool {
+	return len(a.tokens) > 0 || len(a.certs) > 0
+}
+
+// token returns the scopes of the verified client certificate of the request, or else of
+// the API token of the Authorization header
+func (a *authenticator) token(r *http.Request) (APIToken, error) {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		subject := r.TLS.VerifiedChains[0][0].Subject
+		if token, ok := a.certs[subject.CommonName]; ok {
+			return token, nil
+		}
+		if token, ok := a.certs[subject.String()]; ok {
+			return token, nil
+		}
+	}
+
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return APIToken{}, fmt.Errorf("missing bearer token in the Authorization header")
+	}
+
+	token, ok := a.tokens[sha256.Sum256([]byte(strings.TrimPrefix(header, "Bearer ")))]
+	if !ok {
+		return APIToken{}, fmt.Errorf("invalid bearer token")
+	}
+
+	return token, nil
+}
+
+// Authenticate is the middleware checking that the client certificate or the bearer token of
+// a request grants the scope of its route
+func (s *Server) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.auth.enabled() || isProbe(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, err := s.auth.token(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write(newError(err).marshal())
+			return
+		}
+
+		if entry := auditEntry(r); entry != nil {
+			entry.Caller = token.Name
+		}
+
+		scope, status, err := s.requiredScope(r)
+		if err != nil {
+			w.WriteHeader(status)
+			w.Write(newError(err).marshal())
+			return
+		}
+
+		if scope != "" && !token.allows(scope) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write(newError(fmt.Errorf("api token %s lacks the %s scope", token.Name, scope)).marshal())
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requiredScope returns the scope the route of the request needs, no scope means any valid
+// token will do. On failure it returns the http status to reply with.
+func (s *Server) requiredScope(r *http.Request) (string, int, error) {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ScopeAll, http.StatusOK, nil
+	}
+
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return "", http.StatusInternalServerError, err
+	}
+
+	switch template {
+	case "/version", "/metrics":
+		return "", http.StatusOK, nil
+	case "/keys", "/keys/{name}":
+		if r.Method == http.MethodGet {
+			return ScopeKeysRead, http.StatusOK, nil
+		}
+		return ScopeKeysWrite, http.StatusOK, nil
+	case "/keys/{name}/unlock", "/keys/{name}/lock":
+		return ScopeSignPrefix + mux.Vars(r)["name"], http.StatusOK, nil
+	case "/tx/sign", "/tx/submit":
+		name, status, err := s.signingKey(r, template)
+		if err != nil {
+			return "", status, err
+		}
+		return ScopeSignPrefix + name, http.StatusOK, nil
+	default:
+		return ScopeTx, http.StatusOK, nil
+	}
+}
+
+// signingKey returns the name of the key a sign or submit request signs with, the body is
+// decoded the way the handler of the route decodes it and put back for the handler
+func (s *Server) signingKey(r *http.Request, template string) (string, int, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return "", http.StatusBadRequest, err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var name, token string
+	if template == "/tx/submit" {
+		var sb SubmitBody
+		if err := cdc.UnmarshalJSON(body, &sb); err != nil {
+			return "", http.StatusBadRequest, err
+		}
+		name, token = sb.Name, sb.Token
+	} else {
+		var sb SignBody
+		if err := cdc.UnmarshalJSON(body, &sb); err != nil {
+			return "", http.StatusBadRequest, err
+		}
+		name, token = sb.Name, sb.Token
+	}
+
+	if name == "" && token != "" {
+		ss, err := s.sessions.get(token)
+		if err != nil {
+			return "", http.StatusUnauthorized, err
+		}
+		return ss.name, http.StatusOK, nil
+	}
+
+	return name, http.StatusOK, nil
+}
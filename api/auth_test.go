@@ -0,0 +1,125 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/stretchr/testify/require"
+)
+
+// authRoute sends a request with the bearer token and checks the status of the response
+func authRoute(t *testing.T, method, route, token string, data []byte, expStatus int) []byte {
+	req, err := http.NewRequest(method, route, bytes.NewBuffer(data))
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	out, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, expStatus, resp.StatusCode, string(out))
+	return out
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := &Server{
+		KeyringBackend: KeyringBackendMemory,
+		Tokens: []APIToken{
+			{Name: "admin", Token: "admintoken", Scopes: []string{ScopeAll}},
+			{Name: "reader", Token: "readertoken", Scopes: []string{ScopeKeysRead}},
+			{Name: "signer", Token: "signertoken", Scopes: []string{ScopeSignPrefix + testKey}},
+		},
+	}
+	server := httptest.NewServer(s.Router())
+	defer server.Close()
+	defer s.Close()
+
+	keys := fmt.Sprintf("%s/keys", server.URL)
+	addNP := AddNewKey{Name: testKey, Password: testPass, Mnemonic: sMenominc}
+
+	// test requests without a valid token are rejected in the restError format
+	out := authRoute(t, http.MethodGet, keys, "", nil, 401)
+	var restErr restError
+	require.NoError(t, json.Unmarshal(out, &restErr))
+	require.NotEmpty(t, restErr.Error)
+	authRoute(t, http.MethodGet, keys, "badtoken", nil, 401)
+	authRoute(t, http.MethodGet, fmt.Sprintf("%s/version", server.URL), "", nil, 401)
+	authRoute(t, http.MethodGet, fmt.Sprintf("%s/version", server.URL), "readertoken", nil, 200)
+
+	// test the scopes of the tokens
+	authRoute(t, http.MethodPost, keys, "readertoken", addNP.Marshal(), 403)
+	authRoute(t, http.MethodPost, keys, "admintoken", addNP.Marshal(), 200)
+	authRoute(t, http.MethodGet, keys, "readertoken", nil, 200)
+	authRoute(t, http.MethodGet, fmt.Sprintf("%s/keys/%s", server.URL, testKey), "readertoken", nil, 200)
+	authRoute(t, http.MethodGet, keys, "signertoken", nil, 403)
+	authRoute(t, http.MethodGet, fmt.Sprintf("%s/treasury", server.URL), "readertoken", nil, 403)
+
+	sender, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+	coins := sdk.NewCoins(sdk.NewInt64Coin("uluna", 1000))
+	unsignedTx := auth.NewStdTx([]sdk.Msg{bank.NewMsgSend(sender, sender, coins)}, auth.NewStdFee(200000, coins), nil, "")
+
+	// test signing needs the sign scope of the key
+	signBody := SignBody{Tx: cdc.MustMarshalJSON(unsignedTx), Name: testKey, Passphrase: testPass, ChainID: "testing", AccountNumber: "3", Sequence: "7"}
+	sign := fmt.Sprintf("%s/tx/sign", server.URL)
+	authRoute(t, http.MethodPost, sign, "readertoken", signBody.Marshal(), 403)
+	signed := authRoute(t, http.MethodPost, sign, "signertoken", signBody.Marshal(), 200)
+
+	addNP = AddNewKey{Name: "jim", Password: testPass}
+	authRoute(t, http.MethodPost, keys, "admintoken", addNP.Marshal(), 200)
+	signBody.Name = "jim"
+	authRoute(t, http.MethodPost, sign, "signertoken", signBody.Marshal(), 403)
+
+	// test the scope is checked against the key the handler signs with, a key differing in
+	// case or a duplicate key doesn't name another key
+	smuggled := append(bytes.TrimSuffix(signBody.Marshal(), []byte(`}`)), fmt.Sprintf(`,"Name":%q}`, testKey)...)
+	authRoute(t, http.MethodPost, sign, "signertoken", smuggled, 403)
+	submitBody := SubmitBody{Tx: signBody.Tx, Name: "jim", Passphrase: testPass, ChainID: "testing", AccountNumber: "3", Sequence: "7"}
+	smuggled = append(bytes.TrimSuffix(submitBody.Marshal(), []byte(`}`)), fmt.Sprintf(`,"Name":%q}`, testKey)...)
+	authRoute(t, http.MethodPost, fmt.Sprintf("%s/tx/submit", server.URL), "signertoken", smuggled, 403)
+
+	// test a session token signs for its key, which the sign scope is checked against
+	authRoute(t, http.MethodPost, fmt.Sprintf("%s/keys/jim/unlock", server.URL), "signertoken", UnlockBody{Passphrase: testPass}.Marshal(), 403)
+	out = authRoute(t, http.MethodPost, fmt.Sprintf("%s/keys/%s/unlock", server.URL, testKey), "signertoken", UnlockBody{Passphrase: testPass}.Marshal(), 200)
+	var unlocked UnlockResponse
+	require.NoError(t, json.Unmarshal(out, &unlocked))
+
+	tokenBody := SignBody{Tx: signBody.Tx, Token: unlocked.Token, ChainID: "testing", AccountNumber: "3", Sequence: "7"}
+	require.Equal(t, signed, authRoute(t, http.MethodPost, sign, "signertoken", tokenBody.Marshal(), 200))
+	tokenBody.Token = "unknown"
+	authRoute(t, http.MethodPost, sign, "signertoken", tokenBody.Marshal(), 401)
+}
+
+func TestAuthenticatorConfig(t *testing.T) {
+	_, err := newAuthenticator([]APIToken{{Name: "empty"}}, nil)
+	require.Error(t, err)
+
+	_, err = newAuthenticator([]APIToken{{Name: "a", Token: "token"}, {Name: "b", Token: "token"}}, nil)
+	require.Error(t, err)
+
+	_, err = newAuthenticator(nil, []ClientCert{{Subject: "feeder"}, {Subject: "feeder"}})
+	require.Error(t, err)
+
+	// test the API is open without tokens
+	a, err := newAuthenticator(nil, nil)
+	require.NoError(t, err)
+	require.False(t, a.enabled())
+
+	token := APIToken{Scopes: []string{ScopeSignPrefix + "*", ScopeKeysRead}}
+	require.True(t, token.allows(ScopeSignPrefix+testKey))
+	require.True(t, token.allows(ScopeKeysRead))
+	require.False(t, token.allows(ScopeKeysWrite))
+	require.False(t, token.allows(ScopeTx))
+}
@@ -1,15 +1,36 @@
 package api
 
 import (
-	httpRpcClient "github.com/tendermint/tendermint/rpc/client/http"
+	"context"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"time"
+
+	httpRpcClient "github.com/tendermint/tendermint/rpc/client/http"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/x/auth"
 )
 
-// Broadcast - no-lint
+const (
+	// BroadcastSync returns once the transaction passed CheckTx
+	BroadcastSync = "sync"
+	// BroadcastAsync returns right after the transaction is handed to the node
+	BroadcastAsync = "async"
+	// BroadcastBlock waits on the node until the transaction is committed
+	BroadcastBlock = "block"
+	// BroadcastCommitWait returns once the transaction passed CheckTx, then waits
+	// on the node websocket for its DeliverTx result
+	BroadcastCommitWait = "commit-wait"
+
+	defaultBroadcastTimeout = time.Minute
+)
+
+// Broadcast handles the /tx/broadcast?mode={sync|async|block|commit-wait} route
 func (s *Server) Broadcast(w http.ResponseWriter, r *http.Request) {
 	var stdTx auth.StdTx
 	body, err := ioutil.ReadAll(r.Body)
@@ -26,26 +47,171 @@ func (s *Server) Broadcast(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	txBytes, err := cdc.MarshalBinaryLengthPrefixed(stdTx)
+	entry := auditEntry(r)
+	if entry != nil {
+		entry.Msgs = msgTypes(stdTx.Msgs)
+	}
+
+	res, status, err := s.broadcastTx(stdTx, r.URL.Query().Get("mode"))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(status)
 		w.Write(newError(err).marshal())
 		return
 	}
 
-	client, err := httpRpcClient.New(s.Node, "/websocket")
+	if entry != nil {
+		entry.TxHash = res.TxHash
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(cdc.MustMarshalJSON(res))
+	return
+}
+
+// broadcastTx broadcasts a signed transaction to the node with the given mode,
+// sync is used when mode is empty. On failure it returns the http status to reply with.
+func (s *Server) broadcastTx(stdTx auth.StdTx, mode string) (res sdk.TxResponse, status int, err error) {
+	txBytes, err := cdc.MarshalBinaryLengthPrefixed(stdTx)
 	if err != nil {
-		return
+		return res, http.StatusBadRequest, err
+	}
+
+	switch mode {
+	case "", BroadcastSync:
+		err = s.nodes.broadcast(false, func(client *httpRpcClient.HTTP) error {
+			result, err := client.BroadcastTxSync(txBytes)
+			if err == nil {
+				res = sdk.NewResponseFormatBroadcastTx(result)
+			}
+			return err
+		})
+	case BroadcastAsync:
+		err = s.nodes.broadcast(false, func(client *httpRpcClient.HTTP) error {
+			result, err := client.BroadcastTxAsync(txBytes)
+			if err == nil {
+				res = sdk.NewResponseFormatBroadcastTx(result)
+			}
+			return err
+		})
+	case BroadcastBlock:
+		err = s.nodes.broadcast(true, func(client *httpRpcClient.HTTP) error {
+			result, err := client.BroadcastTxCommit(txBytes)
+			if err == nil {
+				res = sdk.NewResponseFormatBroadcastTxCommit(result)
+			}
+			return err
+		})
+	case BroadcastCommitWait:
+		res, status, err = s.broadcastTxCommitWait(txBytes)
+		if err != nil {
+			s.observeRPC("broadcast", err)
+			// past the timeout the transaction was broadcast and may still be committed
+			if status != http.StatusGatewayTimeout {
+				s.resetSequences(stdTx)
+			}
+			return res, status, err
+		}
+	default:
+		return res, http.StatusBadRequest, fmt.Errorf("invalid broadcast mode %s", mode)
 	}
 
-	res, err := client.BroadcastTxSync(txBytes)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write(newError(err).marshal())
-		return
+		s.observeRPC("broadcast", err)
+		s.resetSequences(stdTx)
+		return res, http.StatusBadRequest, err
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write(cdc.MustMarshalJSON(sdk.NewResponseFormatBroadcastTx(res)))
-	return
+	if mode == "" {
+		mode = BroadcastSync
+	}
+	s.instruments().broadcasts.WithLabelValues(mode, res.Codespace, strconv.FormatUint(uint64(res.Code), 10)).Inc()
+
+	// a transaction failing CheckTx leaves its sequence unused, and one failing with a
+	// sequence mismatch means the tracked sequence went out of sync with the chain
+	if res.Code != 0 {
+		s.resetSequences(stdTx)
+	}
+
+	return res, http.StatusOK, nil
+}
+
+// resetSequences reseeds the sequences of the signers of a transaction that may not have used
+// its sequence from chain state on their next sign
+func (s *Server) resetSequences(stdTx auth.StdTx) {
+	for _, signer := range stdTx.GetSigners() {
+		s.sequences.Reset(signer)
+	}
+}
+
+// BroadcastWait returns how long block and commit-wait broadcasts wait for the block,
+// BroadcastTimeout or its default when not configured
+func (s *Server) BroadcastWait() time.Duration {
+	if s.BroadcastTimeout == 0 {
+		return defaultBroadcastTimeout
+	}
+
+	return s.BroadcastTimeout
+}
+
+// broadcastTxCommitWait subscribes to the transaction on the websocket of a healthy node, broadcasts
+// it in sync mode and waits for the DeliverTx result until the broadcast timeout elapses
+func (s *Server) broadcastTxCommitWait(txBytes []byte) (res sdk.TxResponse, status int, err error) {
+	remote, err := s.nodes.remote()
+	if err != nil {
+		return res, http.StatusBadGateway, err
+	}
+
+	timeout := s.BroadcastWait()
+
+	// the websocket subscription needs a client of its own, the pooled ones are shared
+	client, err := newRPCClient(remote, timeout)
+	if err != nil {
+		return res, http.StatusBadGateway, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err = client.Start(); err != nil {
+		return res, http.StatusBadGateway, err
+	}
+	defer client.Stop()
+
+	tx := tmtypes.Tx(txBytes)
+	query := tmtypes.EventQueryTxFor(tx).String()
+
+	// subscribe before broadcasting, so the event can't be missed
+	events, err := client.Subscribe(ctx, "keyserver", query)
+	if err != nil {
+		return res, http.StatusBadGateway, err
+	}
+	defer client.Unsubscribe(context.Background(), "keyserver", query)
+
+	result, err := client.BroadcastTxSync(txBytes)
+	if err != nil {
+		return res, http.StatusBadRequest, err
+	}
+
+	// CheckTx failed, the transaction will never be included
+	if result.Code != 0 {
+		return sdk.NewResponseFormatBroadcastTx(result), http.StatusOK, nil
+	}
+
+	select {
+	case event := <-events:
+		data, ok := event.Data.(tmtypes.EventDataTx)
+		if !ok {
+			return res, http.StatusBadGateway, fmt.Errorf("unexpected event data %T", event.Data)
+		}
+
+		return sdk.NewResponseResultTx(&ctypes.ResultTx{
+			Hash:     tx.Hash(),
+			Height:   data.Height,
+			Index:    data.Index,
+			TxResult: data.Result,
+			Tx:       tx,
+		}, nil, ""), http.StatusOK, nil
+	case <-ctx.Done():
+		return res, http.StatusGatewayTimeout, fmt.Errorf("timed out after %s waiting for tx %X to be included in a block", timeout, tx.Hash())
+	}
 }
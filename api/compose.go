@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// ComposeBody contains the data to compose a transaction out of any msgs,
+// Msgs is an amino JSON array of msgs registered in the app codec
+type ComposeBody struct {
+	Msgs    json.RawMessage `json:"msgs"`
+	ChainID string          `json:"chain_id"`
+	Memo    string          `json:"memo,omitempty"`
+	FeeOptions
+}
+
+// Marshal - nolint
+func (cb ComposeBody) Marshal() []byte {
+	out, err := json.Marshal(cb)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// decodeMsgs decodes an amino JSON array of msgs
+func decodeMsgs(raw json.RawMessage) (msgs []sdk.Msg, err error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("must include msgs with request")
+	}
+
+	if err := cdc.UnmarshalJSON(raw, &msgs); err != nil {
+		return nil, fmt.Errorf("failed to decode msgs: %s", err.Error())
+	}
+
+	if len(msgs) == 0 {
+		return nil, fmt.Errorf("must include msgs with request")
+	}
+
+	for i, msg := range msgs {
+		if msg == nil {
+			return nil, fmt.Errorf("msg %d is empty", i)
+		}
+	}
+
+	return msgs, nil
+}
+
+// Compose handles the /tx/compose route
+func (s *Server) Compose(w http.ResponseWriter, r *http.Request) {
+	var cb ComposeBody
+
+	err := readTxBody(r, &cb)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	msgs, err := decodeMsgs(cb.Msgs)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	s.writeTx(w, msgs, cb.Memo, cb.FeeOptions)
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/tendermint/tendermint/libs/bytes"
+
+	"github.com/terra-project/core/x/distribution"
+)
+
+// WithdrawRewardsBody contains the necessary data to make a withdraw rewards transaction,
+// rewards are withdrawn from every validator the delegator is bonded to when Validators is empty
+type WithdrawRewardsBody struct {
+	Delegator  sdk.AccAddress   `json:"delegator"`
+	Validators []sdk.ValAddress `json:"validators,omitempty"`
+	ChainID    string           `json:"chain_id"`
+	Memo       string           `json:"memo,omitempty"`
+	FeeOptions
+}
+
+// Marshal - nolint
+func (wb WithdrawRewardsBody) Marshal() []byte {
+	out, err := json.Marshal(wb)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// WithdrawRewards handles the /tx/distribution/withdraw route
+func (s *Server) WithdrawRewards(w http.ResponseWriter, r *http.Request) {
+	var wb WithdrawRewardsBody
+
+	err := readTxBody(r, &wb)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	validators := wb.Validators
+	if len(validators) == 0 {
+		validators, err = s.LoadDelegatorValidators(wb.Delegator)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(newError(fmt.Errorf("failed to load validators of %s: %s", wb.Delegator, err.Error())).marshal())
+			return
+		}
+
+		if len(validators) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(newError(fmt.Errorf("%s has no delegations", wb.Delegator)).marshal())
+			return
+		}
+	}
+
+	msgs := make([]sdk.Msg, 0, len(validators))
+	for _, validator := range validators {
+		msgs = append(msgs, distribution.NewMsgWithdrawDelegatorReward(wb.Delegator, validator))
+	}
+
+	s.writeTx(w, msgs, wb.Memo, wb.FeeOptions)
+}
+
+// LoadDelegatorValidators load the validators a delegator is bonded to
+func (s *Server) LoadDelegatorValidators(delegator sdk.AccAddress) (res []sdk.ValAddress, err error) {
+	bz, err := cdc.MarshalJSON(distribution.NewQueryDelegatorParams(delegator))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.queryABCI(
+		fmt.Sprintf("custom/%s/%s", distribution.QuerierRoute, distribution.QueryDelegatorValidators),
+		bytes.HexBytes(bz),
+	)
+	if err != nil {
+		return
+	}
+
+	if !result.Response.IsOK() {
+		return nil, errors.New(result.Response.Log)
+	}
+
+	var validators []sdk.ValAddress
+	if err := cdc.UnmarshalJSON(result.Response.Value, &validators); err != nil {
+		return nil, err
+	}
+
+	return validators, nil
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+)
+
+// EstimateBody contains the transaction to estimate the fee of, either an unsigned
+// StdTx in Tx or an amino JSON array of msgs in Msgs
+type EstimateBody struct {
+	Tx   json.RawMessage `json:"tx,omitempty"`
+	Msgs json.RawMessage `json:"msgs,omitempty"`
+	Memo string          `json:"memo,omitempty"`
+	FeeOptions
+}
+
+// Marshal - nolint
+func (eb EstimateBody) Marshal() []byte {
+	out, err := json.Marshal(eb)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// msgs returns the msgs and memo of the transaction to estimate
+func (eb EstimateBody) msgs() (msgs []sdk.Msg, memo string, err error) {
+	if (len(eb.Tx) == 0) == (len(eb.Msgs) == 0) {
+		return nil, "", fmt.Errorf("must include exactly one of tx or msgs with request")
+	}
+
+	if len(eb.Msgs) != 0 {
+		msgs, err = decodeMsgs(eb.Msgs)
+		return msgs, eb.Memo, err
+	}
+
+	var stdTx auth.StdTx
+	if err := cdc.UnmarshalJSON(eb.Tx, &stdTx); err != nil {
+		return nil, "", err
+	}
+
+	if len(stdTx.Msgs) == 0 {
+		return nil, "", fmt.Errorf("tx has no msgs")
+	}
+
+	memo = stdTx.Memo
+	if eb.Memo != "" {
+		memo = eb.Memo
+	}
+
+	return stdTx.Msgs, memo, nil
+}
+
+// Estimate handles the /tx/estimate route
+func (s *Server) Estimate(w http.ResponseWriter, r *http.Request) {
+	var eb EstimateBody
+
+	err := readTxBody(r, &eb)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	msgs, memo, err := eb.msgs()
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	estimate, err := s.estimateFee(msgs, memo, eb.FeeOptions)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	// fees given are charged as they are, the tax the chain expects is reported next to them
+	if eb.Fees != "" {
+		estimate.Tax, err = s.ComputeTax(msgs)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(newError(err).marshal())
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(cdc.MustMarshalJSON(estimate))
+	return
+}
@@ -0,0 +1,255 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/cosmos/cosmos-sdk/client/flags"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+
+	core "github.com/terra-project/core/types"
+	marketexported "github.com/terra-project/core/x/market/exported"
+	msgauthexported "github.com/terra-project/core/x/msgauth/exported"
+	wasmexported "github.com/terra-project/core/x/wasm/exported"
+)
+
+// FeeOptions are the fee settings of the request bodies building a transaction, which embed them
+type FeeOptions struct {
+	Fees          string `json:"fees,omitempty"`
+	Gas           string `json:"gas,omitempty"`
+	GasPrices     string `json:"gas_prices,omitempty"`
+	GasAdjustment string `json:"gas_adjustment,omitempty"`
+}
+
+func (fo *FeeOptions) feeOptions() *FeeOptions {
+	return fo
+}
+
+// txBody is a request body embedding FeeOptions
+type txBody interface {
+	feeOptions() *FeeOptions
+}
+
+// readTxBody decodes the body of the request into body. Amino skips embedded structs, so the
+// fee options are decoded on their own.
+func readTxBody(r *http.Request, body txBody) error {
+	bz, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	if err := cdc.UnmarshalJSON(bz, body); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(bz, body.feeOptions())
+}
+
+// writeTx builds an unsigned transaction for msgs and writes it as the response
+func (s *Server) writeTx(w http.ResponseWriter, msgs []sdk.Msg, memo string, opts FeeOptions) {
+	stdTx, err := s.buildTx(msgs, memo, opts)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(cdc.MustMarshalJSON(stdTx))
+}
+
+// FeeEstimate is the breakdown of the fee of a transaction
+type FeeEstimate struct {
+	// SimulatedGas is left out when the gas was given
+	SimulatedGas uint64    `json:"simulated_gas,omitempty"`
+	Gas          uint64    `json:"gas"`
+	GasFee       sdk.Coins `json:"gas_fee"`
+	Tax          sdk.Coins `json:"tax"`
+	Fee          sdk.Coins `json:"fee"`
+}
+
+// buildTx builds an unsigned transaction for msgs. Gas is simulated when it is not given,
+// and unless fees are given explicitly the fee is the gas fee plus the stability tax.
+func (s *Server) buildTx(msgs []sdk.Msg, memo string, opts FeeOptions) (stdTx auth.StdTx, err error) {
+	estimate, err := s.estimateFee(msgs, memo, opts)
+	if err != nil {
+		return stdTx, err
+	}
+
+	return auth.NewStdTx(
+		msgs,
+		auth.NewStdFee(estimate.Gas, estimate.Fee),
+		[]auth.StdSignature{},
+		memo,
+	), nil
+}
+
+// estimateFee validates msgs and estimates the gas and fee buildTx uses for them
+func (s *Server) estimateFee(msgs []sdk.Msg, memo string, opts FeeOptions) (estimate FeeEstimate, err error) {
+	for _, msg := range msgs {
+		if err := msg.ValidateBasic(); err != nil {
+			return estimate, err
+		}
+	}
+
+	var fees sdk.Coins
+	if opts.Fees != "" {
+		if opts.GasPrices != "" {
+			return estimate, fmt.Errorf("GasPrices and Fees cannot be used at the same time")
+		}
+
+		fees, err = sdk.ParseCoins(opts.Fees)
+		if err != nil {
+			return estimate, fmt.Errorf("failed to parse fees %s into sdk.Coins", opts.Fees)
+		}
+	}
+
+	var gasPrices sdk.DecCoins
+	if opts.GasPrices != "" {
+		gasPrices, err = sdk.ParseDecCoins(opts.GasPrices)
+		if err != nil {
+			return estimate, fmt.Errorf("failed to parse gasPrices %s into sdk.DecCoins", opts.GasPrices)
+		}
+	}
+
+	// dummy fee & dummy gas limit
+	var feesForSim sdk.Coins
+	if !fees.Empty() {
+		feesForSim = sdk.NewCoins(fees...)
+	} else if !gasPrices.Empty() {
+		feesForSim = sdk.NewCoins(sdk.NewCoin(gasPrices[0].Denom, sdk.NewInt(1)))
+	} else if denom := firstDenom(taxPrincipals(msgs)); denom != "" {
+		feesForSim = sdk.NewCoins(sdk.NewCoin(denom, sdk.NewInt(1)))
+	} else {
+		feesForSim = sdk.NewCoins(sdk.NewCoin(core.MicroLunaDenom, sdk.NewInt(1)))
+	}
+
+	stdTx := auth.NewStdTx(
+		msgs,
+		auth.NewStdFee(flags.DefaultGasLimit, feesForSim),
+		[]auth.StdSignature{{}},
+		memo,
+	)
+
+	var gas uint64
+	if opts.Gas != "" {
+		gas, err = strconv.ParseUint(opts.Gas, 10, 64)
+	} else {
+		gas, err = s.SimulateGas(cdc.MustMarshalBinaryLengthPrefixed(stdTx))
+		estimate.SimulatedGas = gas
+	}
+
+	if err != nil {
+		return estimate, fmt.Errorf("failed to parse gas %s into uint64; %s", opts.Gas, err.Error())
+	}
+
+	if gas != 0 && opts.GasAdjustment != "" {
+		adj, err := strconv.ParseFloat(opts.GasAdjustment, 64)
+		if err != nil {
+			return estimate, fmt.Errorf("failed to parse gasAdjustment %s into float64", opts.GasAdjustment)
+		}
+		gas = uint64(adj * float64(gas))
+	}
+	estimate.Gas = gas
+
+	estimate.GasFee = sdk.NewCoins()
+	for _, gasPrice := range gasPrices {
+		fee := sdk.NewCoin(gasPrice.Denom, gasPrice.Amount.MulInt64(int64(gas)).Ceil().TruncateInt())
+		estimate.GasFee = estimate.GasFee.Add(fee)
+	}
+	fees = fees.Add(estimate.GasFee...)
+
+	estimate.Tax = sdk.NewCoins()
+	if opts.Fees == "" {
+		estimate.Tax, err = s.ComputeTax(msgs)
+		if err != nil {
+			return estimate, err
+		}
+
+		fees = fees.Add(estimate.Tax...)
+	}
+	estimate.Fee = fees
+
+	return estimate, nil
+}
+
+// ComputeTax computes the stability tax the chain charges on msgs. Like the Terra
+// ante handler, the tax cap is applied to each taxed principal separately.
+func (s *Server) ComputeTax(msgs []sdk.Msg) (taxes sdk.Coins, err error) {
+	principals := taxPrincipals(msgs)
+	if len(principals) == 0 {
+		return sdk.NewCoins(), nil
+	}
+
+	taxRate, err := s.TaxRate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tax rate: %s", err.Error())
+	}
+
+	taxes = sdk.NewCoins()
+	for _, principal := range principals {
+		for _, coin := range principal {
+			if coin.Denom == core.MicroLunaDenom || coin.Denom == sdk.DefaultBondDenom {
+				continue
+			}
+
+			taxCap, err := s.TaxCap(coin.Denom)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load tax cap: %s", err.Error())
+			}
+
+			taxDue := taxRate.MulInt(coin.Amount).TruncateInt()
+			if taxDue.GT(taxCap) {
+				taxDue = taxCap
+			}
+
+			if taxDue.IsZero() {
+				continue
+			}
+
+			taxes = taxes.Add(sdk.NewCoin(coin.Denom, taxDue))
+		}
+	}
+
+	return taxes, nil
+}
+
+// taxPrincipals returns the amounts the Terra ante handler levies stability tax on,
+// each capped on its own
+func taxPrincipals(msgs []sdk.Msg) (principals []sdk.Coins) {
+	for _, msg := range msgs {
+		switch msg := msg.(type) {
+		case bank.MsgSend:
+			principals = append(principals, msg.Amount)
+		case bank.MsgMultiSend:
+			for _, input := range msg.Inputs {
+				principals = append(principals, input.Coins)
+			}
+		case marketexported.MsgSwapSend:
+			principals = append(principals, sdk.NewCoins(msg.OfferCoin))
+		case wasmexported.MsgInstantiateContract:
+			principals = append(principals, msg.InitCoins)
+		case wasmexported.MsgExecuteContract:
+			principals = append(principals, msg.Coins)
+		case msgauthexported.MsgExecAuthorized:
+			principals = append(principals, taxPrincipals(msg.Msgs)...)
+		}
+	}
+
+	return principals
+}
+
+func firstDenom(principals []sdk.Coins) string {
+	for _, principal := range principals {
+		if !principal.Empty() {
+			return principal[0].Denom
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terra-project/core/x/market"
+	"github.com/terra-project/core/x/staking"
+)
+
+func TestTaxPrincipals(t *testing.T) {
+	sender, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+	validator, err := sdk.ValAddressFromBech32(sVal)
+	require.NoError(t, err)
+
+	sent := sdk.NewCoins(sdk.NewInt64Coin("ukrw", 1000), sdk.NewInt64Coin("uusd", 10))
+	inputs := []sdk.Coins{
+		sdk.NewCoins(sdk.NewInt64Coin("usdr", 5)),
+		sdk.NewCoins(sdk.NewInt64Coin("ukrw", 7)),
+	}
+
+	principals := taxPrincipals([]sdk.Msg{
+		bank.NewMsgSend(sender, sender, sent),
+		staking.NewMsgDelegate(sender, validator, sdk.NewInt64Coin("uluna", 100)),
+		bank.NewMsgMultiSend(
+			[]bank.Input{bank.NewInput(sender, inputs[0]), bank.NewInput(sender, inputs[1])},
+			[]bank.Output{bank.NewOutput(sender, inputs[0].Add(inputs[1]...))},
+		),
+	})
+	require.Equal(t, []sdk.Coins{sent, inputs[0], inputs[1]}, principals)
+	require.Equal(t, "ukrw", firstDenom(principals))
+
+	// test only swaps sending to another address are taxed
+	offer := sdk.NewInt64Coin("ukrw", 500)
+	principals = taxPrincipals([]sdk.Msg{
+		market.NewMsgSwap(sender, offer, "uusd"),
+		market.NewMsgSwapSend(sender, sender, offer, "uusd"),
+	})
+	require.Equal(t, []sdk.Coins{sdk.NewCoins(offer)}, principals)
+
+	// test untaxed msgs need no tax rate from the node
+	server := &Server{}
+	taxes, err := server.ComputeTax([]sdk.Msg{staking.NewMsgDelegate(sender, validator, sdk.NewInt64Coin("uluna", 100))})
+	require.NoError(t, err)
+	require.True(t, taxes.Empty())
+}
+
+func TestEstimateWithFees(t *testing.T) {
+	// a node with a tax rate of 0.5% capped at 1000
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Params struct {
+				Path string `json:"path"`
+			} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		value := `"0.005"`
+		if req.Params.Path == "custom/treasury/taxCap" {
+			value = `"1000"`
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"response":{"value":"%s"}}}`, req.ID, base64.StdEncoding.EncodeToString([]byte(value)))
+	}))
+	defer node.Close()
+
+	s := &Server{KeyringBackend: KeyringBackendMemory, Node: node.URL}
+	server := httptest.NewServer(s.Router())
+	defer server.Close()
+	defer s.Close()
+
+	sender, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+	msgs := []sdk.Msg{bank.NewMsgSend(sender, sender, sdk.NewCoins(sdk.NewInt64Coin("ukrw", 100000)))}
+
+	// test the tax is reported next to the fees given, which are charged as they are
+	eb := EstimateBody{Msgs: cdc.MustMarshalJSON(msgs), FeeOptions: FeeOptions{Fees: "300ukrw", Gas: "100000"}}
+	out := postRoute(t, fmt.Sprintf("%s/tx/estimate", server.URL), eb.Marshal(), 200)
+	var estimate FeeEstimate
+	require.NoError(t, cdc.UnmarshalJSON(out, &estimate))
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("ukrw", 500)), estimate.Tax)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("ukrw", 300)), estimate.Fee)
+
+	// test no simulated gas is reported when the gas is given
+	var raw map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(out, &raw))
+	require.NotContains(t, raw, "simulated_gas")
+}
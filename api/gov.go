@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govutils "github.com/cosmos/cosmos-sdk/x/gov/client/utils"
+
+	"github.com/terra-project/core/x/gov"
+)
+
+// GovVoteBody contains the necessary data to make a governance vote transaction,
+// Option is one of yes, no, no_with_veto and abstain
+type GovVoteBody struct {
+	Voter      sdk.AccAddress `json:"voter"`
+	ProposalID string         `json:"proposal_id"`
+	Option     string         `json:"option"`
+	ChainID    string         `json:"chain_id"`
+	Memo       string         `json:"memo,omitempty"`
+	FeeOptions
+}
+
+// Marshal - nolint
+func (vb GovVoteBody) Marshal() []byte {
+	out, err := json.Marshal(vb)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// GovDepositBody contains the necessary data to make a governance deposit transaction
+type GovDepositBody struct {
+	Depositor  sdk.AccAddress `json:"depositor"`
+	ProposalID string         `json:"proposal_id"`
+	Amount     string         `json:"amount"`
+	ChainID    string         `json:"chain_id"`
+	Memo       string         `json:"memo,omitempty"`
+	FeeOptions
+}
+
+// Marshal - nolint
+func (db GovDepositBody) Marshal() []byte {
+	out, err := json.Marshal(db)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// GovProposalBody contains the necessary data to make a text proposal transaction
+type GovProposalBody struct {
+	Proposer       sdk.AccAddress `json:"proposer"`
+	Title          string         `json:"title"`
+	Description    string         `json:"description"`
+	InitialDeposit string         `json:"initial_deposit,omitempty"`
+	ChainID        string         `json:"chain_id"`
+	Memo           string         `json:"memo,omitempty"`
+	FeeOptions
+}
+
+// Marshal - nolint
+func (pb GovProposalBody) Marshal() []byte {
+	out, err := json.Marshal(pb)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// GovVote handles the /tx/gov/vote route
+func (s *Server) GovVote(w http.ResponseWriter, r *http.Request) {
+	var vb GovVoteBody
+
+	err := readTxBody(r, &vb)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	proposalID, err := strconv.ParseUint(vb.ProposalID, 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("failed to parse proposal id %s into uint64", vb.ProposalID)).marshal())
+		return
+	}
+
+	option, err := gov.VoteOptionFromString(govutils.NormalizeVoteOption(vb.Option))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("invalid vote option %s", vb.Option)).marshal())
+		return
+	}
+
+	s.writeTx(
+		w,
+		[]sdk.Msg{gov.NewMsgVote(vb.Voter, proposalID, option)},
+		vb.Memo,
+		vb.FeeOptions,
+	)
+}
+
+// GovDeposit handles the /tx/gov/deposit route
+func (s *Server) GovDeposit(w http.ResponseWriter, r *http.Request) {
+	var db GovDepositBody
+
+	err := readTxBody(r, &db)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	proposalID, err := strconv.ParseUint(db.ProposalID, 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("failed to parse proposal id %s into uint64", db.ProposalID)).marshal())
+		return
+	}
+
+	amount, err := sdk.ParseCoins(db.Amount)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("failed to parse amount %s into sdk.Coins", db.Amount)).marshal())
+		return
+	}
+
+	s.writeTx(
+		w,
+		[]sdk.Msg{gov.NewMsgDeposit(db.Depositor, proposalID, amount)},
+		db.Memo,
+		db.FeeOptions,
+	)
+}
+
+// GovProposal handles the /tx/gov/proposal route
+func (s *Server) GovProposal(w http.ResponseWriter, r *http.Request) {
+	var pb GovProposalBody
+
+	err := readTxBody(r, &pb)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	initialDeposit, err := sdk.ParseCoins(pb.InitialDeposit)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("failed to parse initial deposit %s into sdk.Coins", pb.InitialDeposit)).marshal())
+		return
+	}
+
+	s.writeTx(
+		w,
+		[]sdk.Msg{gov.NewMsgSubmitProposal(gov.NewTextProposal(pb.Title, pb.Description), initialDeposit, pb.Proposer)},
+		pb.Memo,
+		pb.FeeOptions,
+	)
+}
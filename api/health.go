@@ -0,0 +1,147 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gorilla/mux"
+	httprpcclient "github.com/tendermint/tendermint/rpc/client/http"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+// Health statuses
+const (
+	HealthOK          = "ok"
+	HealthUnavailable = "unavailable"
+)
+
+// HealthCheck is the result of one readiness check
+type HealthCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HealthResponse is the response of the /healthz and /readyz routes
+type HealthResponse struct {
+	Status string        `json:"status"`
+	Checks []HealthCheck `json:"checks,omitempty"`
+}
+
+// Marshal - nolint
+func (hr HealthResponse) Marshal() []byte {
+	out, err := json.Marshal(hr)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// newHealthCheck returns the check named name failed with err, or passed without
+func newHealthCheck(name string, err error) HealthCheck {
+	if err != nil {
+		return HealthCheck{Name: name, Status: HealthUnavailable, Error: err.Error()}
+	}
+
+	return HealthCheck{Name: name, Status: HealthOK}
+}
+
+// Healthz handles the /healthz route, the server is alive as long as it answers
+func (s *Server) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(HealthResponse{Status: HealthOK}.Marshal())
+}
+
+// Readyz handles the /readyz route, the server is ready when the keybase can be read and the
+// node answers, is on ChainID and is in sync
+func (s *Server) Readyz(w http.ResponseWriter, r *http.Request) {
+	checks := []HealthCheck{newHealthCheck("keybase", s.checkKeybase())}
+	checks = append(checks, s.checkNode()...)
+
+	res := HealthResponse{Status: HealthOK, Checks: checks}
+	status := http.StatusOK
+	for _, check := range checks {
+		if check.Status != HealthOK {
+			res.Status = HealthUnavailable
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(res.Marshal())
+}
+
+// isProbe returns whether the request is a liveness or readiness probe, which are served
+// without credentials
+func isProbe(r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+
+	template, err := route.GetPathTemplate()
+	return err == nil && (template == "/healthz" || template == "/readyz")
+}
+
+// checkKeybase checks the keybase is open and its directory can be read
+func (s *Server) checkKeybase() error {
+	if s.keybase == nil {
+		return fmt.Errorf("keybase isn't open")
+	}
+
+	if strings.ToLower(s.KeyringBackend) == KeyringBackendMemory {
+		return nil
+	}
+
+	dir, err := os.Open(s.KeyDir)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	if _, err := dir.Readdirnames(1); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read %s: %s", s.KeyDir, err.Error())
+	}
+
+	return nil
+}
+
+// checkNode checks the node answers status, is on ChainID when configured and isn't
+// catching up
+func (s *Server) checkNode() []HealthCheck {
+	var status *ctypes.ResultStatus
+	err := s.nodes.do(func(client *httprpcclient.HTTP) (err error) {
+		status, err = client.Status()
+		return err
+	})
+	if err != nil {
+		unreachable := fmt.Errorf("node is unreachable")
+		return []HealthCheck{
+			newHealthCheck("node", err),
+			newHealthCheck("chain_id", unreachable),
+			newHealthCheck("sync", unreachable),
+		}
+	}
+
+	var chainErr error
+	if s.ChainID != "" && status.NodeInfo.Network != s.ChainID {
+		chainErr = fmt.Errorf("node is on chain %s, expected %s", status.NodeInfo.Network, s.ChainID)
+	}
+
+	var syncErr error
+	if status.SyncInfo.CatchingUp {
+		syncErr = fmt.Errorf("node is catching up at height %d", status.SyncInfo.LatestBlockHeight)
+	}
+
+	return []HealthCheck{
+		newHealthCheck("node", nil),
+		newHealthCheck("chain_id", chainErr),
+		newHealthCheck("sync", syncErr),
+	}
+}
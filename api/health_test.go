@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// getHealth fetches the probe route and checks the status of the response
+func getHealth(t *testing.T, route string, expStatus int) (res HealthResponse) {
+	resp, err := http.Get(route)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, expStatus, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	return res
+}
+
+// healthChecks returns the status of every check by name
+func healthChecks(res HealthResponse) map[string]string {
+	statuses := make(map[string]string)
+	for _, check := range res.Checks {
+		statuses[check.Name] = check.Status
+	}
+	return statuses
+}
+
+func TestHealthz(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	inSync := fakeNode(`{"node_info":{"network":"testing"},"sync_info":{"latest_block_height":"10","catching_up":false},"validator_info":{}}`, nil)
+	defer inSync.Close()
+	catchingUp := fakeNode(`{"node_info":{"network":"testing"},"sync_info":{"latest_block_height":"10","catching_up":true},"validator_info":{}}`, nil)
+	defer catchingUp.Close()
+
+	// test the probes need no token
+	s := &Server{KeyDir: dir, Node: inSync.URL, ChainID: "testing", Tokens: []APIToken{{Name: "admin", Token: "admintoken", Scopes: []string{ScopeAll}}}}
+	server := httptest.NewServer(s.Router())
+	defer server.Close()
+	defer s.Close()
+
+	require.Equal(t, HealthOK, getHealth(t, fmt.Sprintf("%s/healthz", server.URL), 200).Status)
+	res := getHealth(t, fmt.Sprintf("%s/readyz", server.URL), 200)
+	require.Equal(t, HealthOK, res.Status)
+	require.Equal(t, map[string]string{"keybase": HealthOK, "node": HealthOK, "chain_id": HealthOK, "sync": HealthOK}, healthChecks(res))
+
+	// test a node on another chain isn't ready
+	s.ChainID = "columbus-4"
+	res = getHealth(t, fmt.Sprintf("%s/readyz", server.URL), 503)
+	require.Equal(t, HealthUnavailable, res.Status)
+	require.Equal(t, map[string]string{"keybase": HealthOK, "node": HealthOK, "chain_id": HealthUnavailable, "sync": HealthOK}, healthChecks(res))
+
+	// test a node catching up isn't ready
+	s.ChainID = "testing"
+	s.nodes = newNodePool([]string{catchingUp.URL}, 0, 0)
+	res = getHealth(t, fmt.Sprintf("%s/readyz", server.URL), 503)
+	require.Equal(t, map[string]string{"keybase": HealthOK, "node": HealthOK, "chain_id": HealthOK, "sync": HealthUnavailable}, healthChecks(res))
+
+	// test an unreachable node and an unreadable keybase directory
+	catchingUp.Close()
+	require.NoError(t, os.RemoveAll(dir))
+	res = getHealth(t, fmt.Sprintf("%s/readyz", server.URL), 503)
+	require.Equal(t, map[string]string{"keybase": HealthUnavailable, "node": HealthUnavailable, "chain_id": HealthUnavailable, "sync": HealthUnavailable}, healthChecks(res))
+	for _, check := range res.Checks {
+		require.NotEmpty(t, check.Error)
+	}
+	require.Equal(t, HealthOK, getHealth(t, fmt.Sprintf("%s/healthz", server.URL), 200).Status)
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	ckeys "github.com/cosmos/cosmos-sdk/crypto/keys"
+	"github.com/tendermint/tendermint/crypto"
+)
+
+const (
+	// KeyringBackendLegacy is the LevelDB keybase in KeyDir, used when no backend is configured
+	KeyringBackendLegacy = "legacy"
+	// KeyringBackendMemory keeps keys in memory only, they are lost on restart
+	KeyringBackendMemory = "memory"
+	// KeyringBackendFile is the keyring encrypted with KeyringPassphrase in KeyDir
+	KeyringBackendFile = ckeys.BackendFile
+	// KeyringBackendOS is the keyring of the operating system
+	KeyringBackendOS = ckeys.BackendOS
+	// KeyringBackendTest is the unencrypted keyring in KeyDir, for testing only
+	KeyringBackendTest = ckeys.BackendTest
+	// KeyringBackendPKCS11 keeps keys on the token in PKCS11Slot of PKCS11Library
+	KeyringBackendPKCS11 = "pkcs11"
+
+	keyringAppName = "keyserver"
+)
+
+// errKeybaseClosed is returned by keybase calls after the server was closed
+var errKeybaseClosed = fmt.Errorf("keybase is closed")
+
+// Keybase is the key storage the handlers use
+type Keybase interface {
+	List() ([]ckeys.Info, error)
+	Get(name string) (ckeys.Info, error)
+	// CreateAccount creates the key unless a key with the same name exists
+	CreateAccount(name, mnemonic, bip39Passwd, encryptPasswd, hdPath string, algo ckeys.SigningAlgo) (ckeys.Info, error)
+	Update(name, oldpass string, getNewpass func() (string, error)) error
+	Delete(name, passphrase string, skipPass bool) error
+	Sign(name, passphrase string, msg []byte) ([]byte, crypto.PubKey, error)
+	// ExportPrivateKeyObject fails with errKeyOnToken for keys that can't leave the token
+	ExportPrivateKeyObject(name, passphrase string) (crypto.PrivKey, error)
+	Close() error
+}
+
+// keyStore is the part of a cosmos-sdk keybase the keyserver uses, which the pkcs11 backend implements
+type keyStore interface {
+	List() ([]ckeys.Info, error)
+	Get(name string) (ckeys.Info, error)
+	CreateAccount(name, mnemonic, bip39Passwd, encryptPasswd, hdPath string, algo ckeys.SigningAlgo) (ckeys.Info, error)
+	Update(name, oldpass string, getNewpass func() (string, error)) error
+	Delete(name, passphrase string, skipPass bool) error
+	Sign(name, passphrase string, msg []byte) ([]byte, crypto.PubKey, error)
+	ExportPrivateKeyObject(name, passphrase string) (crypto.PrivKey, error)
+	CloseDB()
+}
+
+// OpenKeybase opens the keybase of KeyringBackend in KeyDir, the server uses it until Close
+func (s *Server) OpenKeybase() error {
+	backend := strings.ToLower(s.KeyringBackend)
+	defer func(start time.Time) {
+		s.instruments().keybaseOpen.WithLabelValues(backend).Set(time.Since(start).Seconds())
+	}(time.Now())
+
+	if backend != KeyringBackendMemory {
+		if err := os.MkdirAll(s.KeyDir, 0700); err != nil {
+			return err
+		}
+	}
+
+	var kb keyStore
+	switch backend {
+	case "", KeyringBackendLegacy:
+		if err := os.MkdirAll(filepath.Join(s.KeyDir, "keys"), 0700); err != nil {
+			return err
+		}
+
+		var err error
+		kb, err = newLevelDBKeybase(s.KeyDir)
+		if err != nil {
+			return err
+		}
+	case KeyringBackendMemory:
+		kb = ckeys.NewInMemory()
+	case KeyringBackendPKCS11:
+		token, err := newPKCS11Keybase(s.PKCS11Library, s.PKCS11Slot, s.PKCS11PIN)
+		if err != nil {
+			return err
+		}
+
+		kb, err = newPassphraseKeybase(token, filepath.Join(s.KeyDir, "keyring-pkcs11-passphrases.json"))
+		if err != nil {
+			token.CloseDB()
+			return err
+		}
+	default:
+		// the file backend reads its passphrase twice when it creates the keyring
+		input := strings.NewReader(fmt.Sprintf("%s\n%s\n", s.KeyringPassphrase, s.KeyringPassphrase))
+
+		keyring, err := ckeys.NewKeyring(keyringAppName, backend, s.KeyDir, input)
+		if err != nil {
+			return err
+		}
+
+		kb, err = newPassphraseKeybase(keyring, filepath.Join(s.KeyDir, fmt.Sprintf("keyring-%s-passphrases.json", backend)))
+		if err != nil {
+			return err
+		}
+	}
+
+	// the token session can only run one operation at a time
+	s.keybase = &lockedKeybase{kb: kb, exclusive: backend == KeyringBackendPKCS11}
+	return nil
+}
+
+// lockedKeybase guards a keybase against calls after Close. Reads and signing run in
+// parallel, the calls writing keys run one at a time, alone.
+type lockedKeybase struct {
+	mtx sync.RWMutex
+	kb  keyStore
+	// exclusive runs every call alone, for keybases that can't run calls in parallel
+	exclusive bool
+	closed    bool
+}
+
+// read runs fn on the keybase along with the other reads
+func (k *lockedKeybase) read(fn func(kb keyStore) error) error {
+	if k.exclusive {
+		return k.write(fn)
+	}
+
+	k.mtx.RLock()
+	defer k.mtx.RUnlock()
+
+	if k.closed {
+		return errKeybaseClosed
+	}
+
+	return fn(k.kb)
+}
+
+// write runs fn on the keybase alone
+func (k *lockedKeybase) write(fn func(kb keyStore) error) error {
+	k.mtx.Lock()
+	defer k.mtx.Unlock()
+
+	if k.closed {
+		return errKeybaseClosed
+	}
+
+	return fn(k.kb)
+}
+
+// List - nolint
+func (k *lockedKeybase) List() (infos []ckeys.Info, err error) {
+	err = k.read(func(kb keyStore) (err error) {
+		infos, err = kb.List()
+		return err
+	})
+	return infos, err
+}
+
+// Get - nolint
+func (k *lockedKeybase) Get(name string) (info ckeys.Info, err error) {
+	err = k.read(func(kb keyStore) (err error) {
+		info, err = kb.Get(name)
+		return err
+	})
+	return info, err
+}
+
+// CreateAccount - nolint
+func (k *lockedKeybase) CreateAccount(name, mnemonic, bip39Passwd, encryptPasswd, hdPath string, algo ckeys.SigningAlgo) (info ckeys.Info, err error) {
+	err = k.write(func(kb keyStore) (err error) {
+		if _, err := kb.Get(name); err == nil {
+			return fmt.Errorf("key %s already exists", name)
+		}
+
+		info, err = kb.CreateAccount(name, mnemonic, bip39Passwd, encryptPasswd, hdPath, algo)
+		return err
+	})
+	return info, err
+}
+
+// Update - nolint
+func (k *lockedKeybase) Update(name, oldpass string, getNewpass func() (string, error)) error {
+	return k.write(func(kb keyStore) error {
+		return kb.Update(name, oldpass, getNewpass)
+	})
+}
+
+// Delete - nolint
+func (k *lockedKeybase) Delete(name, passphrase string, skipPass bool) error {
+	return k.write(func(kb keyStore) error {
+		return kb.Delete(name, passphrase, skipPass)
+	})
+}
+
+// Sign - nolint
+func (k *lockedKeybase) Sign(name, passphrase string, msg []byte) (sig []byte, pub crypto.PubKey, err error) {
+	err = k.read(func(kb keyStore) (err error) {
+		sig, pub, err = kb.Sign(name, passphrase, msg)
+		return err
+	})
+	return sig, pub, err
+}
+
+// ExportPrivateKeyObject - nolint
+func (k *lockedKeybase) ExportPrivateKeyObject(name, passphrase string) (priv crypto.PrivKey, err error) {
+	err = k.read(func(kb keyStore) (err error) {
+		priv, err = kb.ExportPrivateKeyObject(name, passphrase)
+		return err
+	})
+	return priv, err
+}
+
+// Close waits for the running calls, closes the keybase and fails every later call
+func (k *lockedKeybase) Close() error {
+	k.mtx.Lock()
+	defer k.mtx.Unlock()
+
+	if !k.closed {
+		k.closed = true
+		k.kb.CloseDB()
+	}
+
+	return nil
+}
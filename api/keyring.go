@@ -0,0 +1,218 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/99designs/keyring"
+	ckeys "github.com/cosmos/cosmos-sdk/crypto/keys"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/keyerror"
+	"github.com/tendermint/tendermint/crypto"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passphraseKeybase checks key passphrases for the keyring and pkcs11 backends, which protect
+// keys on their own and ignore the passphrase given with each call. It keeps bcrypt hashes of the
+// passphrases in a file next to the keyring, and reports missing keys like the other backends.
+// A bcrypt check takes tens of milliseconds, so the passphrase last verified for each key is
+// remembered in memory as an HMAC under a key of the process, and checked against that first.
+type passphraseKeybase struct {
+	keyStore
+
+	path   string
+	hashes map[string][]byte
+
+	mtx      sync.Mutex
+	macKey   []byte
+	verified map[string][]byte
+}
+
+func newPassphraseKeybase(kb keyStore, path string) (*passphraseKeybase, error) {
+	macKey := make([]byte, 32)
+	if _, err := rand.Read(macKey); err != nil {
+		return nil, err
+	}
+
+	pkb := &passphraseKeybase{keyStore: kb, path: path, hashes: make(map[string][]byte), macKey: macKey, verified: make(map[string][]byte)}
+
+	bz, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return pkb, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(bz, &pkb.hashes); err != nil {
+		return nil, fmt.Errorf("failed to read passphrases from %s: %s", path, err.Error())
+	}
+
+	return pkb, nil
+}
+
+// save writes the passphrase hashes, replacing the file at once
+func (kb *passphraseKeybase) save() error {
+	bz, err := json.Marshal(kb.hashes)
+	if err != nil {
+		return err
+	}
+
+	tmp := kb.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, bz, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, kb.path)
+}
+
+func (kb *passphraseKeybase) checkPassphrase(name, passphrase string) error {
+	hash, ok := kb.hashes[name]
+	if !ok {
+		return fmt.Errorf("key %s has no passphrase, keys in a keyring must be created through the keyserver", name)
+	}
+
+	mac := hmac.New(sha256.New, kb.macKey)
+	mac.Write([]byte(passphrase))
+	sum := mac.Sum(nil)
+
+	kb.mtx.Lock()
+	verified, ok := kb.verified[name]
+	kb.mtx.Unlock()
+	if ok && hmac.Equal(sum, verified) {
+		return nil
+	}
+
+	if bcrypt.CompareHashAndPassword(hash, []byte(passphrase)) != nil {
+		return keyerror.NewErrWrongPassword()
+	}
+
+	kb.mtx.Lock()
+	kb.verified[name] = sum
+	kb.mtx.Unlock()
+
+	return nil
+}
+
+// forget drops the verified passphrase of the key, after its passphrase changed or it was deleted
+func (kb *passphraseKeybase) forget(name string) {
+	kb.mtx.Lock()
+	defer kb.mtx.Unlock()
+
+	delete(kb.verified, name)
+}
+
+// Get - nolint
+func (kb *passphraseKeybase) Get(name string) (ckeys.Info, error) {
+	info, err := kb.keyStore.Get(name)
+	if err == keyring.ErrKeyNotFound {
+		return nil, keyerror.NewErrKeyNotFound(name)
+	}
+
+	return info, err
+}
+
+// CreateAccount - nolint
+func (kb *passphraseKeybase) CreateAccount(name, mnemonic, bip39Passwd, encryptPasswd, hdPath string, algo ckeys.SigningAlgo) (ckeys.Info, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(encryptPasswd), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := kb.keyStore.CreateAccount(name, mnemonic, bip39Passwd, encryptPasswd, hdPath, algo)
+	if err != nil {
+		return nil, err
+	}
+
+	kb.hashes[name] = hash
+	if err := kb.save(); err != nil {
+		// a key nobody can sign with is of no use
+		delete(kb.hashes, name)
+		kb.keyStore.Delete(name, encryptPasswd, true)
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// Update changes the passphrase of the key, the keyring encryption stays the same
+func (kb *passphraseKeybase) Update(name, oldpass string, getNewpass func() (string, error)) error {
+	if _, err := kb.Get(name); err != nil {
+		return err
+	}
+
+	if err := kb.checkPassphrase(name, oldpass); err != nil {
+		return err
+	}
+
+	newpass, err := getNewpass()
+	if err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newpass), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	old := kb.hashes[name]
+	kb.hashes[name] = hash
+	if err := kb.save(); err != nil {
+		kb.hashes[name] = old
+		return err
+	}
+
+	kb.forget(name)
+	return nil
+}
+
+// Delete - nolint
+func (kb *passphraseKeybase) Delete(name, passphrase string, skipPass bool) error {
+	if _, err := kb.Get(name); err != nil {
+		return err
+	}
+
+	if !skipPass {
+		if err := kb.checkPassphrase(name, passphrase); err != nil {
+			return err
+		}
+	}
+
+	if err := kb.keyStore.Delete(name, passphrase, skipPass); err != nil {
+		return err
+	}
+
+	delete(kb.hashes, name)
+	kb.forget(name)
+	return kb.save()
+}
+
+// Sign - nolint
+func (kb *passphraseKeybase) Sign(name, passphrase string, msg []byte) ([]byte, crypto.PubKey, error) {
+	if _, err := kb.Get(name); err != nil {
+		return nil, nil, err
+	}
+
+	if err := kb.checkPassphrase(name, passphrase); err != nil {
+		return nil, nil, err
+	}
+
+	return kb.keyStore.Sign(name, passphrase, msg)
+}
+
+// ExportPrivateKeyObject - nolint
+func (kb *passphraseKeybase) ExportPrivateKeyObject(name, passphrase string) (crypto.PrivKey, error) {
+	if _, err := kb.Get(name); err != nil {
+		return nil, err
+	}
+
+	if err := kb.checkPassphrase(name, passphrase); err != nil {
+		return nil, err
+	}
+
+	return kb.keyStore.ExportPrivateKeyObject(name, passphrase)
+}
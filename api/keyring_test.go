@@ -0,0 +1,118 @@
+package api
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	ckeys "github.com/cosmos/cosmos-sdk/crypto/keys"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyringBackends(t *testing.T) {
+	for _, backend := range []string{KeyringBackendLegacy, KeyringBackendMemory, KeyringBackendTest, KeyringBackendFile} {
+		t.Run(backend, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "")
+			require.NoError(t, err)
+
+			s := &Server{KeyDir: dir, KeyringBackend: backend, KeyringPassphrase: "keyringpass"}
+			require.NoError(t, s.OpenKeybase())
+			server := httptest.NewServer(s.Router())
+			defer server.Close()
+			defer s.Close()
+
+			addNP := AddNewKey{Name: testKey, Password: testPass, Mnemonic: sMenominc}
+			key := unmarshalKeyOutput(postRoute(t, fmt.Sprintf("%s/keys", server.URL), addNP.Marshal(), 200))
+			require.Equal(t, sAcc, key.Address)
+			postRoute(t, fmt.Sprintf("%s/keys", server.URL), addNP.Marshal(), 400)
+
+			sender, err := sdk.AccAddressFromBech32(sAcc)
+			require.NoError(t, err)
+			coins := sdk.NewCoins(sdk.NewInt64Coin("uluna", 1000))
+			unsignedTx := auth.NewStdTx([]sdk.Msg{bank.NewMsgSend(sender, sender, coins)}, auth.NewStdFee(200000, coins), nil, "")
+
+			// test the passphrase of the key is checked
+			signBody := SignBody{Tx: cdc.MustMarshalJSON(unsignedTx), Name: testKey, Passphrase: testPass, ChainID: "testing", AccountNumber: "3", Sequence: "7"}
+			postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), signBody.Marshal(), 200)
+			signBody.Passphrase = testPassAlt
+			postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), signBody.Marshal(), 500)
+
+			// test updating the passphrase
+			updatePass := UpdateKeyBody{OldPassword: testPass, NewPassword: testPassAlt}
+			putRoute(t, fmt.Sprintf("%s/keys/%s", server.URL, testKey), updatePass.Marshal(), 200)
+			postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), signBody.Marshal(), 200)
+
+			// test deleting w/ the old passphrase fails, only the keyring reports a wrong password as such
+			wrongPassStatus := 401
+			if backend == KeyringBackendLegacy || backend == KeyringBackendMemory {
+				wrongPassStatus = 500
+			}
+			deleteKey := DeleteKeyBody{Password: testPass}
+			deleteRoute(t, fmt.Sprintf("%s/keys/%s", server.URL, testKey), deleteKey.Marshal(), wrongPassStatus)
+			deleteKey.Password = testPassAlt
+			deleteRoute(t, fmt.Sprintf("%s/keys/%s", server.URL, testKey), deleteKey.Marshal(), 200)
+			getRoute(t, fmt.Sprintf("%s/keys/%s", server.URL, testKey), 404)
+		})
+	}
+}
+
+func TestPassphraseKeybaseVerified(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+
+	keyring, err := ckeys.NewKeyring(keyringAppName, KeyringBackendTest, dir, nil)
+	require.NoError(t, err)
+	kb, err := newPassphraseKeybase(keyring, filepath.Join(dir, "passphrases.json"))
+	require.NoError(t, err)
+	_, err = kb.CreateAccount(testKey, sMenominc, ckeys.DefaultBIP39Passphrase, testPass, "44'/330'/0'/0/0", ckeys.Secp256k1)
+	require.NoError(t, err)
+
+	// test a verified passphrase is remembered, and other passphrases are still checked
+	_, _, err = kb.Sign(testKey, testPass, []byte("msg"))
+	require.NoError(t, err)
+	require.Contains(t, kb.verified, testKey)
+	_, _, err = kb.Sign(testKey, testPass, []byte("msg"))
+	require.NoError(t, err)
+	_, _, err = kb.Sign(testKey, testPassAlt, []byte("msg"))
+	require.Error(t, err)
+
+	// test the remembered passphrase is dropped when it changes
+	require.NoError(t, kb.Update(testKey, testPass, func() (string, error) { return testPassAlt, nil }))
+	require.NotContains(t, kb.verified, testKey)
+	_, _, err = kb.Sign(testKey, testPass, []byte("msg"))
+	require.Error(t, err)
+	_, _, err = kb.Sign(testKey, testPassAlt, []byte("msg"))
+	require.NoError(t, err)
+}
+
+func TestLegacyKeybaseReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+
+	s := &Server{KeyDir: dir}
+	require.NoError(t, s.OpenKeybase())
+	_, err = s.keybase.CreateAccount(testKey, sMenominc, ckeys.DefaultBIP39Passphrase, testPass, "44'/330'/0'/0/0", ckeys.Secp256k1)
+	require.NoError(t, err)
+
+	// test the database stays open, a second keybase can't take its lock
+	require.Error(t, (&Server{KeyDir: dir}).OpenKeybase())
+
+	// test close releases the database and the key was stored
+	require.NoError(t, s.Close())
+	s = &Server{KeyDir: dir}
+	require.NoError(t, s.OpenKeybase())
+	defer s.Close()
+
+	info, err := s.keybase.Get(testKey)
+	require.NoError(t, err)
+	require.Equal(t, sAcc, info.GetAddress().String())
+
+	infos, err := s.keybase.List()
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+}
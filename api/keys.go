@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
-	"github.com/cosmos/cosmos-sdk/client/keys"
 	ckeys "github.com/cosmos/cosmos-sdk/crypto/keys"
 	"github.com/cosmos/cosmos-sdk/crypto/keys/keyerror"
 	bip39 "github.com/cosmos/go-bip39"
@@ -17,14 +17,7 @@ import (
 func (s *Server) GetKeys(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	kb, err := keys.NewKeyBaseFromDir(s.KeyDir)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write(newError(err).marshal())
-		return
-	}
-
-	infos, err := kb.List()
+	infos, err := s.keybase.List()
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write(newError(err).marshal())
@@ -78,13 +71,6 @@ func (ak AddNewKey) Marshal() []byte {
 func (s *Server) PostKeys(w http.ResponseWriter, r *http.Request) {
 	var m AddNewKey
 
-	kb, err := keys.NewKeyBaseFromDir(s.KeyDir)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write(newError(err).marshal())
-		return
-	}
-
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -99,19 +85,30 @@ func (s *Server) PostKeys(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if entry := auditEntry(r); entry != nil {
+		entry.Key = m.Name
+	}
+
 	if m.Name == "" || m.Password == "" {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write(newError(fmt.Errorf("must include both password and name with request")).marshal())
 		return
 	}
 
-	// if mnemonic is empty, generate one
+	// if mnemonic is empty, generate one, except for a token which generates the key itself
 	mnemonic := m.Mnemonic
-	if mnemonic == "" {
+	onToken := strings.ToLower(s.KeyringBackend) == KeyringBackendPKCS11
+	if mnemonic == "" && !onToken {
 		_, mnemonic, _ = ckeys.NewInMemory().CreateMnemonic("inmemorykey", ckeys.English, "123456789", ckeys.Secp256k1)
 	}
 
-	if !bip39.IsMnemonicValid(mnemonic) {
+	if onToken && mnemonic != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("keys on a pkcs11 token can't be imported from a mnemonic")).marshal())
+		return
+	}
+
+	if !onToken && !bip39.IsMnemonicValid(mnemonic) {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write(newError(fmt.Errorf("invalid mnemonic")).marshal())
 		return
@@ -129,18 +126,11 @@ func (s *Server) PostKeys(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = kb.Get(m.Name)
-	if err == nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write(newError(fmt.Errorf("key %s already exists", m.Name)).marshal())
-		return
-	}
-
 	account := uint32(m.Account)
 	index := uint32(m.Index)
 
 	hdpath := fmt.Sprintf("44'/330'/%d'/0/%d", account, index)
-	info, err := kb.CreateAccount(m.Name, mnemonic, ckeys.DefaultBIP39Passphrase, m.Password, hdpath, ckeys.Secp256k1)
+	info, err := s.keybase.CreateAccount(m.Name, mnemonic, ckeys.DefaultBIP39Passphrase, m.Password, hdpath, ckeys.Secp256k1)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write(newError(err).marshal())
@@ -172,13 +162,6 @@ func (s *Server) PostKeys(w http.ResponseWriter, r *http.Request) {
 func (s *Server) GetKey(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	kb, err := keys.NewKeyBaseFromDir(s.KeyDir)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write(newError(err).marshal())
-		return
-	}
-
 	vars := mux.Vars(r)
 	name := vars["name"]
 	bechPrefix := r.URL.Query().Get("bech")
@@ -194,7 +177,7 @@ func (s *Server) GetKey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	info, err := kb.Get(name)
+	info, err := s.keybase.Get(name)
 	if keyerror.IsErrKeyNotFound(err) {
 		w.WriteHeader(http.StatusNotFound)
 		w.Write(newError(err).marshal())
@@ -260,22 +243,15 @@ func (s *Server) PutKey(w http.ResponseWriter, r *http.Request) {
 	name := vars["name"]
 	var m UpdateKeyBody
 
-	kb, err := keys.NewKeyBaseFromDir(s.KeyDir)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write(newError(err).marshal())
-		return
-	}
-
 	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&m)
+	err := decoder.Decode(&m)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write(newError(err).marshal())
 		return
 	}
 
-	err = kb.Update(name, m.OldPassword, func() (string, error) { return m.NewPassword, nil })
+	err = s.keybase.Update(name, m.OldPassword, func() (string, error) { return m.NewPassword, nil })
 	if keyerror.IsErrKeyNotFound(err) {
 		w.WriteHeader(http.StatusNotFound)
 		w.Write(newError(err).marshal())
@@ -290,6 +266,9 @@ func (s *Server) PutKey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// sessions unlocked with the old passphrase end with it
+	s.sessions.lockKey(name)
+
 	w.WriteHeader(http.StatusOK)
 	return
 }
@@ -314,22 +293,15 @@ func (s *Server) DeleteKey(w http.ResponseWriter, r *http.Request) {
 	name := vars["name"]
 	var m DeleteKeyBody
 
-	kb, err := keys.NewKeyBaseFromDir(s.KeyDir)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write(newError(err).marshal())
-		return
-	}
-
 	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&m)
+	err := decoder.Decode(&m)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write(newError(err).marshal())
 		return
 	}
 
-	err = kb.Delete(name, m.Password, false)
+	err = s.keybase.Delete(name, m.Password, false)
 	if keyerror.IsErrKeyNotFound(err) {
 		w.WriteHeader(http.StatusNotFound)
 		w.Write(newError(err).marshal())
@@ -344,6 +316,8 @@ func (s *Server) DeleteKey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.sessions.lockKey(name)
+
 	w.WriteHeader(http.StatusOK)
 	return
 }
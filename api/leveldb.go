@@ -0,0 +1,201 @@
+package api
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	ckeys "github.com/cosmos/cosmos-sdk/crypto/keys"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/keyerror"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/mintkey"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/tendermint/tendermint/crypto"
+	dbm "github.com/tendermint/tm-db"
+)
+
+// levelDBKeybase is the LevelDB keybase of the legacy backend, opened once for the life of the
+// server. The cosmos-sdk one opens the database on every call, which fails while another call
+// holds it open, and can't be given an open database. Keys are stored the way cosmos-sdk stores
+// them, and the cosmos-sdk keybase runs each call on a copy of the key in memory.
+type levelDBKeybase struct {
+	db dbm.DB
+}
+
+func newLevelDBKeybase(keyDir string) (*levelDBKeybase, error) {
+	db, err := sdk.NewLevelDB("keys", filepath.Join(keyDir, "keys"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &levelDBKeybase{db: db}, nil
+}
+
+func levelDBInfoKey(name string) []byte {
+	return []byte(fmt.Sprintf("%s.info", name))
+}
+
+func levelDBAddrKey(address sdk.AccAddress) []byte {
+	return []byte(fmt.Sprintf("%s.address", address.String()))
+}
+
+// load returns a memory keybase holding a copy of the key
+func (kb *levelDBKeybase) load(name string) (ckeys.Keybase, error) {
+	bz, err := kb.db.Get(levelDBInfoKey(name))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bz) == 0 {
+		return nil, keyerror.NewErrKeyNotFound(name)
+	}
+
+	mem := ckeys.NewInMemory()
+	if err := mem.Import(name, mintkey.ArmorInfoBytes(bz)); err != nil {
+		return nil, err
+	}
+
+	return mem, nil
+}
+
+// store writes the key of the memory keybase to the database
+func (kb *levelDBKeybase) store(mem ckeys.Keybase, name string) (ckeys.Info, error) {
+	info, err := mem.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	armor, err := mem.Export(name)
+	if err != nil {
+		return nil, err
+	}
+
+	bz, err := mintkey.UnarmorInfoBytes(armor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := kb.db.SetSync(levelDBInfoKey(name), bz); err != nil {
+		return nil, err
+	}
+
+	// the index by address of the cosmos-sdk keybase
+	if err := kb.db.SetSync(levelDBAddrKey(info.GetAddress()), levelDBInfoKey(name)); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// List - nolint
+func (kb *levelDBKeybase) List() ([]ckeys.Info, error) {
+	iter, err := kb.db.Iterator(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var infos []ckeys.Info
+	for ; iter.Valid(); iter.Next() {
+		if !strings.HasSuffix(string(iter.Key()), ".info") {
+			continue
+		}
+
+		var info ckeys.Info
+		if err := ckeys.CryptoCdc.UnmarshalBinaryLengthPrefixed(iter.Value(), &info); err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// Get - nolint
+func (kb *levelDBKeybase) Get(name string) (ckeys.Info, error) {
+	bz, err := kb.db.Get(levelDBInfoKey(name))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bz) == 0 {
+		return nil, keyerror.NewErrKeyNotFound(name)
+	}
+
+	var info ckeys.Info
+	err = ckeys.CryptoCdc.UnmarshalBinaryLengthPrefixed(bz, &info)
+	return info, err
+}
+
+// CreateAccount - nolint
+func (kb *levelDBKeybase) CreateAccount(name, mnemonic, bip39Passwd, encryptPasswd, hdPath string, algo ckeys.SigningAlgo) (ckeys.Info, error) {
+	mem := ckeys.NewInMemory()
+	if _, err := mem.CreateAccount(name, mnemonic, bip39Passwd, encryptPasswd, hdPath, algo); err != nil {
+		return nil, err
+	}
+
+	return kb.store(mem, name)
+}
+
+// Update - nolint
+func (kb *levelDBKeybase) Update(name, oldpass string, getNewpass func() (string, error)) error {
+	mem, err := kb.load(name)
+	if err != nil {
+		return err
+	}
+
+	if err := mem.Update(name, oldpass, getNewpass); err != nil {
+		return err
+	}
+
+	_, err = kb.store(mem, name)
+	return err
+}
+
+// Delete - nolint
+func (kb *levelDBKeybase) Delete(name, passphrase string, skipPass bool) error {
+	mem, err := kb.load(name)
+	if err != nil {
+		return err
+	}
+
+	info, err := mem.Get(name)
+	if err != nil {
+		return err
+	}
+
+	// checks the passphrase
+	if err := mem.Delete(name, passphrase, skipPass); err != nil {
+		return err
+	}
+
+	if err := kb.db.DeleteSync(levelDBAddrKey(info.GetAddress())); err != nil {
+		return err
+	}
+
+	return kb.db.DeleteSync(levelDBInfoKey(name))
+}
+
+// Sign - nolint
+func (kb *levelDBKeybase) Sign(name, passphrase string, msg []byte) ([]byte, crypto.PubKey, error) {
+	mem, err := kb.load(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return mem.Sign(name, passphrase, msg)
+}
+
+// ExportPrivateKeyObject - nolint
+func (kb *levelDBKeybase) ExportPrivateKeyObject(name, passphrase string) (crypto.PrivKey, error) {
+	mem, err := kb.load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return mem.ExportPrivateKeyObject(name, passphrase)
+}
+
+// CloseDB closes the database and releases its lock
+func (kb *levelDBKeybase) CloseDB() {
+	kb.db.Close()
+}
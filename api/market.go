@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/terra-project/core/x/market"
+)
+
+// SwapBody contains the necessary data to make a swap transaction,
+// the swapped coins are sent to Receiver when it is set
+type SwapBody struct {
+	Trader    sdk.AccAddress `json:"trader"`
+	Receiver  sdk.AccAddress `json:"receiver,omitempty"`
+	OfferCoin string         `json:"offer_coin"`
+	AskDenom  string         `json:"ask_denom"`
+	ChainID   string         `json:"chain_id"`
+	Memo      string         `json:"memo,omitempty"`
+	FeeOptions
+}
+
+// Marshal - nolint
+func (sb SwapBody) Marshal() []byte {
+	out, err := json.Marshal(sb)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// Swap handles the /tx/market/swap route
+func (s *Server) Swap(w http.ResponseWriter, r *http.Request) {
+	var sb SwapBody
+
+	err := readTxBody(r, &sb)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	offerCoin, err := sdk.ParseCoin(sb.OfferCoin)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("failed to parse offer coin %s into sdk.Coin", sb.OfferCoin)).marshal())
+		return
+	}
+
+	// only MsgSwapSend pays stability tax on the offer coin, a plain MsgSwap pays the spread instead
+	var msg sdk.Msg
+	if sb.Receiver.Empty() {
+		msg = market.NewMsgSwap(sb.Trader, offerCoin, sb.AskDenom)
+	} else {
+		msg = market.NewMsgSwapSend(sb.Trader, sb.Receiver, offerCoin, sb.AskDenom)
+	}
+
+	s.writeTx(w, []sdk.Msg{msg}, sb.Memo, sb.FeeOptions)
+}
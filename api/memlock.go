@@ -0,0 +1,32 @@
+//go:build !windows
+// +build !windows
+
+package api
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// allocLocked maps memory of its own for a secret and locks it, so it is never swapped out
+// and unlocking it can't unlock the pages of other secrets
+func allocLocked(size int) ([]byte, error) {
+	b, err := unix.Mmap(-1, 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unix.Mlock(b); err != nil {
+		unix.Munmap(b)
+		return nil, fmt.Errorf("failed to lock memory, check RLIMIT_MEMLOCK: %s", err.Error())
+	}
+
+	return b, nil
+}
+
+// freeLocked unlocks and unmaps memory of allocLocked, which must be zeroed before
+func freeLocked(b []byte) {
+	unix.Munlock(b)
+	unix.Munmap(b)
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "keyserver"
+
+// Sign results of the sign counter
+const (
+	signResultSigned   = "signed"
+	signResultRejected = "rejected"
+	signResultFailed   = "failed"
+)
+
+// metrics are the prometheus collectors of the server, registered on a registry of their own
+// so servers don't share them
+type metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	signs           *prometheus.CounterVec
+	broadcasts      *prometheus.CounterVec
+	simulateGas     prometheus.Histogram
+	rpcErrors       *prometheus.CounterVec
+	keybaseOpen     *prometheus.GaugeVec
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "Requests handled, by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to handle requests, by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		signs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "sign_total",
+			Help:      "Sign operations, by key and result: signed, rejected by the signing policy or failed.",
+		}, []string{"key", "result"}),
+		broadcasts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "broadcast_total",
+			Help:      "Transactions broadcast, by mode and the codespace and ABCI code of the node response.",
+		}, []string{"mode", "codespace", "code"}),
+		simulateGas: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "simulate_gas_duration_seconds",
+			Help:      "Time taken to simulate the gas of transactions on the node.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		rpcErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "rpc_errors_total",
+			Help:      "Failed node rpc requests, by query.",
+		}, []string{"query"}),
+		keybaseOpen: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "keybase_open_duration_seconds",
+			Help:      "Time taken to open the keybase, by keyring backend.",
+		}, []string{"backend"}),
+	}
+
+	m.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.signs,
+		m.broadcasts,
+		m.simulateGas,
+		m.rpcErrors,
+		m.keybaseOpen,
+	)
+
+	return m
+}
+
+// instruments returns the metrics of the server, creating them on first use
+func (s *Server) instruments() *metrics {
+	if s.metrics == nil {
+		s.metrics = newMetrics()
+	}
+
+	return s.metrics
+}
+
+// Metrics handles the /metrics route
+func (s *Server) Metrics(w http.ResponseWriter, r *http.Request) {
+	promhttp.HandlerFor(s.instruments().registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// observeRPC counts a failed rpc request of the query
+func (s *Server) observeRPC(query string, err error) {
+	if err != nil {
+		s.instruments().rpcErrors.WithLabelValues(query).Inc()
+	}
+}
+
+// statusWriter remembers the status code of the response
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	if sw.status == 0 {
+		sw.status = status
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Write(bz []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+	return sw.ResponseWriter.Write(bz)
+}
+
+// Instrument is the middleware counting the requests and their latency by route template,
+// so the key names and hashes in paths don't end up in labels
+func (s *Server) Instrument(next http.Handler) http.Handler {
+	m := s.instruments()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		m.requests.WithLabelValues(route, r.Method, strconv.Itoa(sw.status)).Inc()
+		m.requestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
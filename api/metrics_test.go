@@ -0,0 +1,52 @@
+package api
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	s := &Server{KeyringBackend: KeyringBackendMemory, Policies: []SigningPolicy{{Key: "jim", MaxSpend: "1uluna"}}}
+	require.NoError(t, s.OpenKeybase())
+	server := httptest.NewServer(s.Router())
+	defer server.Close()
+	defer s.Close()
+
+	addNP := AddNewKey{Name: testKey, Password: testPass, Mnemonic: sMenominc}
+	postRoute(t, fmt.Sprintf("%s/keys", server.URL), addNP.Marshal(), 200)
+	addNP = AddNewKey{Name: "jim", Password: testPass}
+	postRoute(t, fmt.Sprintf("%s/keys", server.URL), addNP.Marshal(), 200)
+	getRoute(t, fmt.Sprintf("%s/keys/%s", server.URL, testKey), 200)
+
+	sender, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+	coins := sdk.NewCoins(sdk.NewInt64Coin("uluna", 1000))
+	unsignedTx := auth.NewStdTx([]sdk.Msg{bank.NewMsgSend(sender, sender, coins)}, auth.NewStdFee(200000, coins), nil, "")
+
+	signBody := SignBody{Tx: cdc.MustMarshalJSON(unsignedTx), Name: testKey, Passphrase: testPass, ChainID: "testing", AccountNumber: "3", Sequence: "7"}
+	postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), signBody.Marshal(), 200)
+	signBody.Name = "jim"
+	postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), signBody.Marshal(), 403)
+	signBody.Name = "nobody"
+	postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), signBody.Marshal(), 500)
+
+	// without a node the account can't be loaded
+	signBody = SignBody{Tx: signBody.Tx, Name: testKey, Passphrase: testPass, ChainID: "testing"}
+	postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), signBody.Marshal(), 502)
+
+	out := string(getRoute(t, fmt.Sprintf("%s/metrics", server.URL), 200))
+	require.Contains(t, out, `keyserver_http_requests_total{code="200",method="POST",route="/keys"} 2`)
+	require.Contains(t, out, `keyserver_http_requests_total{code="200",method="GET",route="/keys/{name}"} 1`)
+	require.Contains(t, out, `keyserver_http_request_duration_seconds_count{method="POST",route="/tx/sign"} 4`)
+	require.Contains(t, out, `keyserver_sign_total{key="jack",result="signed"} 1`)
+	require.Contains(t, out, `keyserver_sign_total{key="jim",result="rejected"} 1`)
+	require.NotContains(t, out, `key="nobody"`)
+	require.Contains(t, out, `keyserver_rpc_errors_total{query="account"} 1`)
+	require.Contains(t, out, `keyserver_keybase_open_duration_seconds{backend="memory"}`)
+}
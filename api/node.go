@@ -0,0 +1,312 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tendermint/tendermint/libs/bytes"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	httprpcclient "github.com/tendermint/tendermint/rpc/client/http"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	jsonrpcclient "github.com/tendermint/tendermint/rpc/jsonrpc/client"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+)
+
+const (
+	defaultRPCTimeout          = 15 * time.Second
+	defaultHealthCheckInterval = 10 * time.Second
+
+	// maxBlockAge is how old the latest block of a node may get before the node counts as stalled
+	maxBlockAge = time.Minute
+)
+
+// rpcNode is a long-lived client to one node of the pool
+type rpcNode struct {
+	remote     string
+	client     *httprpcclient.HTTP
+	httpClient *http.Client
+	// waitClient is the client for broadcasts waiting on the block, which outlast the rpc timeout
+	waitClient     *httprpcclient.HTTP
+	waitHTTPClient *http.Client
+	err            error
+	healthy        bool
+}
+
+// nodePool shares rpc clients to the configured nodes across handlers. Requests go to the
+// first healthy node and fail over to the next one when the node can't be reached, broadcasts
+// only when the node can't be connected to.
+type nodePool struct {
+	mtx   sync.RWMutex
+	nodes []*rpcNode
+
+	quit chan struct{}
+	once sync.Once
+}
+
+func newNodePool(remotes []string, timeout, waitTimeout time.Duration) *nodePool {
+	if timeout == 0 {
+		timeout = defaultRPCTimeout
+	}
+
+	if waitTimeout == 0 {
+		waitTimeout = defaultBroadcastTimeout
+	}
+
+	p := &nodePool{quit: make(chan struct{})}
+	for _, remote := range remotes {
+		node := &rpcNode{remote: remote, healthy: true}
+		node.httpClient, node.err = newHTTPClient(remote, timeout)
+		if node.err == nil {
+			node.client, node.err = httprpcclient.NewWithClient(remote, "/websocket", node.httpClient)
+		}
+		if node.err == nil {
+			node.waitHTTPClient, node.err = newHTTPClient(remote, waitTimeout)
+		}
+		if node.err == nil {
+			node.waitClient, node.err = httprpcclient.NewWithClient(remote, "/websocket", node.waitHTTPClient)
+		}
+		p.nodes = append(p.nodes, node)
+	}
+
+	return p
+}
+
+func newRPCClient(remote string, timeout time.Duration) (*httprpcclient.HTTP, error) {
+	httpClient, err := newHTTPClient(remote, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return httprpcclient.NewWithClient(remote, "/websocket", httpClient)
+}
+
+func newHTTPClient(remote string, timeout time.Duration) (*http.Client, error) {
+	httpClient, err := jsonrpcclient.DefaultHTTPClient(remote)
+	if err != nil {
+		return nil, err
+	}
+	httpClient.Timeout = timeout
+
+	return httpClient, nil
+}
+
+// candidates returns the nodes in the order to try them, healthy nodes first
+func (p *nodePool) candidates() []*rpcNode {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	var healthy, unhealthy []*rpcNode
+	for _, node := range p.nodes {
+		if node.healthy {
+			healthy = append(healthy, node)
+		} else {
+			unhealthy = append(unhealthy, node)
+		}
+	}
+
+	return append(healthy, unhealthy...)
+}
+
+func (p *nodePool) setHealthy(node *rpcNode, healthy bool) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	node.healthy = healthy
+}
+
+// do calls fn with the client of each node in turn until fn succeeds or fails with an error
+// returned by the node itself, which another node would return as well
+func (p *nodePool) do(fn func(client *httprpcclient.HTTP) error) error {
+	return p.try(fn, false, isNodeFailure)
+}
+
+// broadcast calls fn like do, with the client waiting on the block when wait is set. It only
+// fails over when the node couldn't be connected to: once the transaction was sent the node may
+// have taken it, and sending it to the next node would broadcast it twice.
+func (p *nodePool) broadcast(wait bool, fn func(client *httprpcclient.HTTP) error) error {
+	return p.try(fn, wait, isConnectionFailure)
+}
+
+// try calls fn with the client of each node in turn until fn succeeds or fails with an error
+// failover doesn't accept
+func (p *nodePool) try(fn func(client *httprpcclient.HTTP) error, wait bool, failover func(err error) bool) (err error) {
+	if p == nil {
+		return fmt.Errorf("no node configured")
+	}
+
+	nodes := p.candidates()
+	if len(nodes) == 0 {
+		return fmt.Errorf("no node configured")
+	}
+
+	for _, node := range nodes {
+		if node.err != nil {
+			err = node.err
+			continue
+		}
+
+		client := node.client
+		if wait {
+			client = node.waitClient
+		}
+
+		err = fn(client)
+		if err == nil || !isNodeFailure(err) {
+			return err
+		}
+
+		p.setHealthy(node, false)
+		if !failover(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// remote returns the address of the node do would try first
+func (p *nodePool) remote() (string, error) {
+	if p == nil {
+		return "", fmt.Errorf("no node configured")
+	}
+
+	for _, node := range p.candidates() {
+		if node.err == nil {
+			return node.remote, nil
+		}
+	}
+
+	return "", fmt.Errorf("no node configured")
+}
+
+// checkHealth marks the nodes that are unreachable, catching up or stalled as unhealthy
+func (p *nodePool) checkHealth() {
+	for _, node := range p.candidates() {
+		if node.err != nil {
+			continue
+		}
+
+		status, err := node.client.Status()
+		healthy := err == nil &&
+			!status.SyncInfo.CatchingUp &&
+			time.Since(status.SyncInfo.LatestBlockTime) < maxBlockAge
+
+		p.setHealthy(node, healthy)
+	}
+}
+
+// start checks the health of the nodes every interval until the pool is stopped
+func (p *nodePool) start(interval time.Duration) {
+	if interval == 0 {
+		interval = defaultHealthCheckInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		p.checkHealth()
+		for {
+			select {
+			case <-ticker.C:
+				p.checkHealth()
+			case <-p.quit:
+				return
+			}
+		}
+	}()
+}
+
+// stop stops the health checks and closes the idle connections to the nodes
+func (p *nodePool) stop() {
+	p.once.Do(func() { close(p.quit) })
+
+	for _, node := range p.candidates() {
+		if node.httpClient != nil {
+			node.httpClient.CloseIdleConnections()
+		}
+		if node.waitHTTPClient != nil {
+			node.waitHTTPClient.CloseIdleConnections()
+		}
+	}
+}
+
+// unreachableError marks an error of a query the node could not serve, so handlers respond
+// with a gateway error rather than blaming the request
+type unreachableError struct {
+	err error
+}
+
+func (e unreachableError) Error() string {
+	return e.err.Error()
+}
+
+// Cause - nolint
+func (e unreachableError) Cause() error {
+	return e.err
+}
+
+// isUnreachable reports whether err was marked by unreachableError
+func isUnreachable(err error) bool {
+	_, ok := err.(unreachableError)
+	return ok
+}
+
+// isNodeFailure reports whether err means the node could not serve the request, as opposed
+// to an error response from the node
+func isNodeFailure(err error) bool {
+	for err != nil {
+		switch err.(type) {
+		case *rpctypes.RPCError, rpctypes.RPCError:
+			return false
+		}
+
+		cause, ok := err.(interface{ Cause() error })
+		if !ok {
+			break
+		}
+		err = cause.Cause()
+	}
+
+	return true
+}
+
+// isConnectionFailure reports whether err means the node couldn't be connected to, so the
+// request was never sent
+func isConnectionFailure(err error) bool {
+	for err != nil {
+		switch err := err.(type) {
+		case *net.OpError:
+			return err.Op == "dial"
+		case *net.DNSError:
+			return true
+		}
+
+		switch wrapper := err.(type) {
+		case interface{ Cause() error }:
+			err = wrapper.Cause()
+		case interface{ Unwrap() error }:
+			err = wrapper.Unwrap()
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// queryABCI runs an abci query against the node pool
+func (s *Server) queryABCI(path string, data bytes.HexBytes) (result *ctypes.ResultABCIQuery, err error) {
+	err = s.nodes.do(func(client *httprpcclient.HTTP) (err error) {
+		result, err = client.ABCIQueryWithOptions(path, data, rpcclient.ABCIQueryOptions{})
+		return err
+	})
+	if err == nil {
+		s.treasury.observe(result.Response.Height)
+	}
+
+	return result, err
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	httprpcclient "github.com/tendermint/tendermint/rpc/client/http"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+)
+
+// fakeNode answers every json-rpc request with result, or with rpcErr when it is set
+func fakeNode(result string, rpcErr *rpctypes.RPCError) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if rpcErr != nil {
+			errBz, _ := json.Marshal(rpcErr)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":%s}`, req.ID, errBz)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+	}))
+}
+
+// fakeNodeMethods answers json-rpc requests by method, with the result or the error of the
+// method, methods without either fail as unknown
+func fakeNodeMethods(results map[string]string, rpcErrs map[string]*rpctypes.RPCError) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if result, ok := results[req.Method]; ok {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+			return
+		}
+
+		rpcErr, ok := rpcErrs[req.Method]
+		if !ok {
+			rpcErr = &rpctypes.RPCError{Code: -32601, Message: "Method not found"}
+		}
+		errBz, _ := json.Marshal(rpcErr)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":%s}`, req.ID, errBz)
+	}))
+}
+
+func TestNodePoolFailover(t *testing.T) {
+	down := fakeNode("", nil)
+	down.Close()
+	up := fakeNode(`{"response":{"value":"MQ=="}}`, nil)
+	defer up.Close()
+
+	s := &Server{Node: down.URL, Nodes: []string{up.URL, down.URL}}
+	s.nodes = newNodePool(s.remotes(), 0, 0)
+	require.Len(t, s.nodes.nodes, 2)
+
+	// test the unreachable node is skipped and marked unhealthy
+	result, err := s.queryABCI("custom/treasury/taxRate", nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte("1"), result.Response.Value)
+	require.False(t, s.nodes.nodes[0].healthy)
+	require.Equal(t, up.URL, s.nodes.candidates()[0].remote)
+
+	remote, err := s.nodes.remote()
+	require.NoError(t, err)
+	require.Equal(t, up.URL, remote)
+}
+
+func TestNodePoolErrorResponse(t *testing.T) {
+	failing := fakeNode("", &rpctypes.RPCError{Code: -32603, Message: "Internal error", Data: "tx not found"})
+	defer failing.Close()
+	up := fakeNode(`{"response":{}}`, nil)
+	defer up.Close()
+
+	// test an error response of the node is returned without failing over
+	pool := newNodePool([]string{failing.URL, up.URL}, 0, 0)
+	_, err := (&Server{nodes: pool}).queryABCI("custom/treasury/taxRate", nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "tx not found")
+	require.True(t, pool.nodes[0].healthy)
+
+	// test a pool without nodes
+	_, err = (&Server{}).queryABCI("custom/treasury/taxRate", nil)
+	require.Error(t, err)
+}
+
+// causer wraps an error the way the rpc client does
+type causer struct{ cause error }
+
+func (c causer) Error() string { return c.cause.Error() }
+func (c causer) Cause() error  { return c.cause }
+
+func TestIsNodeFailure(t *testing.T) {
+	require.True(t, isNodeFailure(causer{fmt.Errorf("connection refused")}))
+	require.False(t, isNodeFailure(causer{causer{&rpctypes.RPCError{Code: -32603}}}))
+}
+
+func TestNodePoolBroadcast(t *testing.T) {
+	var mtx sync.Mutex
+	received := make(map[string]int)
+	node := func(name string, delay time.Duration) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				ID json.RawMessage `json:"id"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+
+			mtx.Lock()
+			received[name]++
+			mtx.Unlock()
+
+			time.Sleep(delay)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"code":0,"data":"","log":"","hash":"AB"}}`, req.ID)
+		}))
+	}
+	count := func(name string) int {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return received[name]
+	}
+	down := node("down", 0)
+	down.Close()
+	slow := node("slow", 300*time.Millisecond)
+	defer slow.Close()
+	up := node("up", 0)
+	defer up.Close()
+
+	broadcast := func(pool *nodePool, wait bool) error {
+		return pool.broadcast(wait, func(client *httprpcclient.HTTP) error {
+			_, err := client.BroadcastTxSync([]byte("tx"))
+			return err
+		})
+	}
+
+	// test a node that can't be connected to is failed over
+	pool := newNodePool([]string{down.URL, up.URL}, 0, 0)
+	require.NoError(t, broadcast(pool, false))
+	require.False(t, pool.nodes[0].healthy)
+	require.Equal(t, 1, count("up"))
+
+	// test a timed out broadcast isn't sent to the next node
+	pool = newNodePool([]string{slow.URL, up.URL}, 100*time.Millisecond, time.Second)
+	require.Error(t, broadcast(pool, false))
+	require.Equal(t, 1, count("up"))
+
+	// test broadcasts waiting on the block have the longer timeout
+	pool = newNodePool([]string{slow.URL, up.URL}, 100*time.Millisecond, time.Second)
+	require.NoError(t, broadcast(pool, true))
+	require.Equal(t, 2, count("slow"))
+	require.Equal(t, 1, count("up"))
+}
+
+func TestIsConnectionFailure(t *testing.T) {
+	_, err := http.Get("http://127.0.0.1:1")
+	require.True(t, isConnectionFailure(causer{err}))
+	require.False(t, isConnectionFailure(causer{fmt.Errorf("EOF")}))
+	require.False(t, isConnectionFailure(&rpctypes.RPCError{Code: -32603}))
+}
+
+func TestNodePoolStop(t *testing.T) {
+	up := fakeNode(`{"response":{"value":"MQ=="}}`, nil)
+	defer up.Close()
+
+	s := &Server{nodes: newNodePool([]string{up.URL, "invalid://node"}, 0, 0)}
+	s.nodes.start(time.Millisecond)
+	_, err := s.queryABCI("custom/treasury/taxRate", nil)
+	require.NoError(t, err)
+
+	// test the pool stops twice without panicking and requests still finish after it stopped
+	s.nodes.stop()
+	s.nodes.stop()
+	_, err = s.queryABCI("custom/treasury/taxRate", nil)
+	require.NoError(t, err)
+}
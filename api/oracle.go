@@ -0,0 +1,297 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/terra-project/core/x/oracle"
+)
+
+// OracleBody contains the necessary data to make oracle prevote and vote transactions,
+// ExchangeRates maps a denom to the exchange rate of Luna in that denom
+type OracleBody struct {
+	Feeder        sdk.AccAddress    `json:"feeder"`
+	Validator     sdk.ValAddress    `json:"validator"`
+	ExchangeRates map[string]string `json:"exchange_rates,omitempty"`
+	ChainID       string            `json:"chain_id"`
+	Memo          string            `json:"memo,omitempty"`
+	FeeOptions
+}
+
+// Marshal - nolint
+func (ob OracleBody) Marshal() []byte {
+	out, err := json.Marshal(ob)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// exchangeRatesString formats the exchange rates the way the oracle module hashes them
+func (ob OracleBody) exchangeRatesString() (string, error) {
+	denoms := make([]string, 0, len(ob.ExchangeRates))
+	for denom := range ob.ExchangeRates {
+		denoms = append(denoms, denom)
+	}
+	sort.Strings(denoms)
+
+	tuples := make([]string, 0, len(denoms))
+	for _, denom := range denoms {
+		rate, err := sdk.NewDecFromStr(ob.ExchangeRates[denom])
+		if err != nil {
+			return "", fmt.Errorf("failed to parse exchange rate %s of %s into sdk.Dec", ob.ExchangeRates[denom], denom)
+		}
+		tuples = append(tuples, rate.String()+denom)
+	}
+
+	return strings.Join(tuples, ","), nil
+}
+
+// prevoteRetention is how long salts are kept, the oracle only accepts the reveal of a prevote
+// in the vote period after it
+const prevoteRetention = time.Hour
+
+// oraclePrevote is the salt and exchange rates behind an aggregate prevote
+type oraclePrevote struct {
+	Salt          string    `json:"salt"`
+	ExchangeRates string    `json:"exchange_rates"`
+	Validator     string    `json:"validator"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// prevoteStore keeps the salts of the aggregate prevotes by their hash until they are revealed.
+// Building a prevote doesn't mean it was broadcast, so the vote reveals the prevote the chain
+// holds for the validator. The salts are written to a file in KeyDir to survive restarts.
+type prevoteStore struct {
+	mtx      sync.Mutex
+	path     string
+	prevotes map[string]oraclePrevote
+}
+
+// newPrevoteStore loads the salts from the file at path, an empty path keeps them in memory only
+func newPrevoteStore(path string) (*prevoteStore, error) {
+	ps := &prevoteStore{path: path, prevotes: make(map[string]oraclePrevote)}
+	if path == "" {
+		return ps, nil
+	}
+
+	bz, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ps, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(bz, &ps.prevotes); err != nil {
+		return nil, fmt.Errorf("failed to read oracle prevotes from %s: %s", path, err.Error())
+	}
+
+	return ps, nil
+}
+
+// save writes the salts, replacing the file at once
+func (ps *prevoteStore) save() error {
+	if ps.path == "" {
+		return nil
+	}
+
+	bz, err := json.Marshal(ps.prevotes)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(ps.path), 0700); err != nil {
+		return err
+	}
+
+	tmp := ps.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, bz, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, ps.path)
+}
+
+func (ps *prevoteStore) get(hash oracle.AggregateVoteHash) (prevote oraclePrevote, ok bool) {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+
+	prevote, ok = ps.prevotes[hash.String()]
+	return
+}
+
+// set stores the salt of the prevote and drops the salts past retention
+func (ps *prevoteStore) set(hash oracle.AggregateVoteHash, prevote oraclePrevote) error {
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+
+	for key, old := range ps.prevotes {
+		if time.Since(old.CreatedAt) > prevoteRetention {
+			delete(ps.prevotes, key)
+		}
+	}
+
+	ps.prevotes[hash.String()] = prevote
+	return ps.save()
+}
+
+// newPrevoteMsg builds an aggregate prevote for the exchange rates with a fresh salt
+func newPrevoteMsg(ob OracleBody) (msg oracle.MsgAggregateExchangeRatePrevote, prevote oraclePrevote, err error) {
+	exchangeRates, err := ob.exchangeRatesString()
+	if err != nil {
+		return msg, prevote, err
+	}
+
+	// the oracle module accepts salts of at most 4 characters
+	bz := make([]byte, 2)
+	if _, err := rand.Read(bz); err != nil {
+		return msg, prevote, err
+	}
+	salt := hex.EncodeToString(bz)
+
+	hash := oracle.GetAggregateVoteHash(salt, exchangeRates, ob.Validator)
+	prevote = oraclePrevote{Salt: salt, ExchangeRates: exchangeRates, Validator: ob.Validator.String(), CreatedAt: time.Now().UTC()}
+	return oracle.NewMsgAggregateExchangeRatePrevote(hash, ob.Feeder, ob.Validator), prevote, nil
+}
+
+// OraclePrevote handles the /tx/oracle/prevote route
+func (s *Server) OraclePrevote(w http.ResponseWriter, r *http.Request) {
+	var ob OracleBody
+
+	err := readTxBody(r, &ob)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	if len(ob.ExchangeRates) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("must include exchange_rates with request")).marshal())
+		return
+	}
+
+	msg, newPrevote, err := newPrevoteMsg(ob)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	stdTx, err := s.buildTx([]sdk.Msg{msg}, ob.Memo, ob.FeeOptions)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	if err := s.prevotes.set(msg.Hash, newPrevote); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(cdc.MustMarshalJSON(stdTx))
+	return
+}
+
+// OracleVote handles the /tx/oracle/vote route. It reveals the prevote the chain holds for the
+// validator, and prevotes the exchange rates of the request in the same transaction when they
+// are given.
+func (s *Server) OracleVote(w http.ResponseWriter, r *http.Request) {
+	var ob OracleBody
+
+	err := readTxBody(r, &ob)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	onChain, status, err := s.LoadAggregatePrevote(ob.Validator)
+	if err != nil {
+		w.WriteHeader(status)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	prevote, ok := s.prevotes.get(onChain.Hash)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("no salt for the prevote %s of %s, it wasn't built by this server", onChain.Hash, ob.Validator)).marshal())
+		return
+	}
+
+	msgs := []sdk.Msg{oracle.NewMsgAggregateExchangeRateVote(prevote.Salt, prevote.ExchangeRates, ob.Feeder, ob.Validator)}
+
+	var msg oracle.MsgAggregateExchangeRatePrevote
+	var newPrevote oraclePrevote
+	if len(ob.ExchangeRates) != 0 {
+		msg, newPrevote, err = newPrevoteMsg(ob)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(newError(err).marshal())
+			return
+		}
+		msgs = append(msgs, msg)
+	}
+
+	stdTx, err := s.buildTx(msgs, ob.Memo, ob.FeeOptions)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	if len(ob.ExchangeRates) != 0 {
+		if err := s.prevotes.set(msg.Hash, newPrevote); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write(newError(err).marshal())
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(cdc.MustMarshalJSON(stdTx))
+	return
+}
+
+// LoadAggregatePrevote loads the aggregate prevote of the validator from the chain, with the
+// status to respond with when it fails
+func (s *Server) LoadAggregatePrevote(validator sdk.ValAddress) (res oracle.AggregateExchangeRatePrevote, status int, err error) {
+	defer func() { s.observeRPC("aggregate_prevote", err) }()
+
+	bz, err := cdc.MarshalJSON(oracle.NewQueryAggregatePrevoteParams(validator))
+	if err != nil {
+		return res, http.StatusInternalServerError, err
+	}
+
+	result, err := s.queryABCI("custom/oracle/aggregatePrevote", bz)
+	if err != nil {
+		return res, http.StatusBadGateway, err
+	}
+
+	// the chain holds no prevote of the validator
+	if !result.Response.IsOK() {
+		return res, http.StatusBadRequest, fmt.Errorf("no prevote to reveal for %s: %s", validator, result.Response.Log)
+	}
+
+	if err := cdc.UnmarshalJSON(result.Response.Value, &res); err != nil {
+		return res, http.StatusBadGateway, err
+	}
+
+	return res, http.StatusOK, nil
+}
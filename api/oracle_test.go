@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terra-project/core/x/oracle"
+)
+
+// fakeOracleNode answers aggregate prevote queries with the prevote set last, or with an
+// error until one is set
+type fakeOracleNode struct {
+	*httptest.Server
+
+	mtx     sync.Mutex
+	prevote *oracle.AggregateExchangeRatePrevote
+}
+
+func newFakeOracleNode() *fakeOracleNode {
+	node := &fakeOracleNode{}
+	node.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		node.mtx.Lock()
+		defer node.mtx.Unlock()
+
+		if node.prevote == nil {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"response":{"code":18,"log":"no aggregate prevote"}}}`, req.ID)
+			return
+		}
+		value := base64.StdEncoding.EncodeToString(cdc.MustMarshalJSON(node.prevote))
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"response":{"value":"%s"}}}`, req.ID, value)
+	}))
+	return node
+}
+
+// broadcast puts the prevote on the fake chain
+func (node *fakeOracleNode) broadcast(msg oracle.MsgAggregateExchangeRatePrevote) {
+	node.mtx.Lock()
+	defer node.mtx.Unlock()
+
+	prevote := oracle.NewAggregateExchangeRatePrevote(msg.Hash, msg.Validator, 10)
+	node.prevote = &prevote
+}
+
+func TestOraclePrevoteAndVote(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	node := newFakeOracleNode()
+	defer node.Close()
+
+	s := &Server{KeyDir: dir, Node: node.URL}
+	server := httptest.NewServer(s.Router())
+	defer server.Close()
+
+	feeder, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+	validator, err := sdk.ValAddressFromBech32(sVal)
+	require.NoError(t, err)
+
+	ob := OracleBody{
+		Feeder:        feeder,
+		Validator:     validator,
+		ExchangeRates: map[string]string{"ukrw": "8900.5", "uusd": "7.25"},
+		ChainID:       "testing",
+		FeeOptions:    FeeOptions{Fees: "1000uluna", Gas: "200000"},
+	}
+
+	// test vote w/o prevote
+	postRoute(t, fmt.Sprintf("%s/tx/oracle/vote", server.URL), ob.Marshal(), 400)
+
+	// test prevote
+	var prevoteTx auth.StdTx
+	require.NoError(t, cdc.UnmarshalJSON(postRoute(t, fmt.Sprintf("%s/tx/oracle/prevote", server.URL), ob.Marshal(), 200), &prevoteTx))
+	require.Len(t, prevoteTx.Msgs, 1)
+	prevote, ok := prevoteTx.Msgs[0].(oracle.MsgAggregateExchangeRatePrevote)
+	require.True(t, ok)
+
+	// test a prevote never broadcast isn't revealed
+	postRoute(t, fmt.Sprintf("%s/tx/oracle/vote", server.URL), ob.Marshal(), 400)
+	node.broadcast(prevote)
+	postRoute(t, fmt.Sprintf("%s/tx/oracle/prevote", server.URL), OracleBody{Feeder: feeder, Validator: validator, ExchangeRates: map[string]string{"uusd": "1"}, ChainID: "testing", FeeOptions: FeeOptions{Fees: "1000uluna", Gas: "200000"}}.Marshal(), 200)
+
+	// test vote reveals the prevote on chain and prevotes the new exchange rates
+	ob.ExchangeRates = map[string]string{"uusd": "7.5"}
+	var voteTx auth.StdTx
+	require.NoError(t, cdc.UnmarshalJSON(postRoute(t, fmt.Sprintf("%s/tx/oracle/vote", server.URL), ob.Marshal(), 200), &voteTx))
+	require.Len(t, voteTx.Msgs, 2)
+	vote, ok := voteTx.Msgs[0].(oracle.MsgAggregateExchangeRateVote)
+	require.True(t, ok)
+	require.Equal(t, "8900.500000000000000000ukrw,7.250000000000000000uusd", vote.ExchangeRates)
+	require.Equal(t, prevote.Hash, oracle.GetAggregateVoteHash(vote.Salt, vote.ExchangeRates, validator))
+	require.NoError(t, vote.ValidateBasic())
+
+	// test the salts survive a restart and the next vote reveals the new prevote
+	nextPrevote, ok := voteTx.Msgs[1].(oracle.MsgAggregateExchangeRatePrevote)
+	require.True(t, ok)
+	node.broadcast(nextPrevote)
+	server.Close()
+	require.NoError(t, s.Close())
+
+	s = &Server{KeyDir: dir, Node: node.URL}
+	server = httptest.NewServer(s.Router())
+	defer server.Close()
+	defer s.Close()
+
+	ob.ExchangeRates = nil
+	require.NoError(t, cdc.UnmarshalJSON(postRoute(t, fmt.Sprintf("%s/tx/oracle/vote", server.URL), ob.Marshal(), 200), &voteTx))
+	require.Len(t, voteTx.Msgs, 1)
+	vote = voteTx.Msgs[0].(oracle.MsgAggregateExchangeRateVote)
+	require.Equal(t, nextPrevote.Hash, oracle.GetAggregateVoteHash(vote.Salt, vote.ExchangeRates, validator))
+
+	// test the node being unreachable
+	node.Close()
+	postRoute(t, fmt.Sprintf("%s/tx/oracle/vote", server.URL), ob.Marshal(), 502)
+}
@@ -0,0 +1,353 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	ckeys "github.com/cosmos/cosmos-sdk/crypto/keys"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/hd"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/keyerror"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/miekg/pkcs11"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+)
+
+// secp256k1OID is the DER encoded object identifier of the secp256k1 curve, 1.3.132.0.10
+var secp256k1OID = []byte{0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x0a}
+
+// errKeyOnToken is returned when the private key of a key on a token is asked for
+var errKeyOnToken = fmt.Errorf("the private key is kept on the token")
+
+// pkcs11Keybase keeps secp256k1 keys on a PKCS#11 token. Keys are generated on the token and
+// marked sensitive and not extractable, so private keys never leave it; the keybase only reads
+// public keys and asks the token for signatures. Keys are found by their label, which is the
+// key name. Passphrases of the keys are checked by the passphraseKeybase wrapping it.
+type pkcs11Keybase struct {
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+}
+
+func newPKCS11Keybase(library string, slot uint, pin string) (*pkcs11Keybase, error) {
+	if library == "" {
+		return nil, fmt.Errorf("pkcs11library is required for the pkcs11 keyring backend")
+	}
+
+	ctx := pkcs11.New(library)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load pkcs11 library %s", library)
+	}
+
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, err
+	}
+
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		ctx.Finalize()
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to open a session on slot %d: %s", slot, err.Error())
+	}
+
+	if err := ctx.Login(session, pkcs11.CKU_USER, pin); err != nil && err != pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+		ctx.CloseSession(session)
+		ctx.Finalize()
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to log in to slot %d: %s", slot, err.Error())
+	}
+
+	return &pkcs11Keybase{ctx: ctx, session: session}, nil
+}
+
+// find returns the handles of the objects matching the template
+func (kb *pkcs11Keybase) find(template []*pkcs11.Attribute) ([]pkcs11.ObjectHandle, error) {
+	if err := kb.ctx.FindObjectsInit(kb.session, template); err != nil {
+		return nil, err
+	}
+	defer kb.ctx.FindObjectsFinal(kb.session)
+
+	var handles []pkcs11.ObjectHandle
+	for {
+		found, _, err := kb.ctx.FindObjects(kb.session, 16)
+		if err != nil {
+			return nil, err
+		}
+		if len(found) == 0 {
+			return handles, nil
+		}
+		handles = append(handles, found...)
+	}
+}
+
+// findKey returns the handle of the key of class with the label name
+func (kb *pkcs11Keybase) findKey(name string, class uint) (pkcs11.ObjectHandle, error) {
+	handles, err := kb.find([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, name),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	switch len(handles) {
+	case 0:
+		return 0, keyerror.NewErrKeyNotFound(name)
+	case 1:
+		return handles[0], nil
+	default:
+		return 0, fmt.Errorf("found %d keys labelled %s on the token", len(handles), name)
+	}
+}
+
+// info reads the label and the public key of the public key object
+func (kb *pkcs11Keybase) info(handle pkcs11.ObjectHandle) (*pkcs11Info, error) {
+	attrs, err := kb.ctx.GetAttributeValue(kb.session, handle, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	name := string(attrs[0].Value)
+	if string(attrs[1].Value) != string(secp256k1OID) {
+		return nil, fmt.Errorf("key %s is not a secp256k1 key", name)
+	}
+
+	pubKey, err := pubKeyFromECPoint(attrs[2].Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the public key of %s: %s", name, err.Error())
+	}
+
+	return &pkcs11Info{Name: name, PubKey: pubKey}, nil
+}
+
+// List returns the secp256k1 keys on the token, other keys are skipped
+func (kb *pkcs11Keybase) List() ([]ckeys.Info, error) {
+	handles, err := kb.find([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, secp256k1OID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]ckeys.Info, 0, len(handles))
+	for _, handle := range handles {
+		info, err := kb.info(handle)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// Get - nolint
+func (kb *pkcs11Keybase) Get(name string) (ckeys.Info, error) {
+	handle, err := kb.findKey(name, pkcs11.CKO_PUBLIC_KEY)
+	if err != nil {
+		return nil, err
+	}
+
+	return kb.info(handle)
+}
+
+// CreateAccount generates a key pair on the token. Keys can't be imported from a mnemonic,
+// that would put the private key outside of the token.
+func (kb *pkcs11Keybase) CreateAccount(name, mnemonic, bip39Passwd, encryptPasswd, hdPath string, algo ckeys.SigningAlgo) (ckeys.Info, error) {
+	if mnemonic != "" {
+		return nil, fmt.Errorf("keys on a pkcs11 token are generated on the token and can't be imported from a mnemonic")
+	}
+	if algo != ckeys.Secp256k1 {
+		return nil, ckeys.ErrUnsupportedSigningAlgo
+	}
+
+	public := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, secp256k1OID),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, name),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, []byte(name)),
+	}
+	private := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, name),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, []byte(name)),
+	}
+
+	pub, priv, err := kb.ctx.GenerateKeyPair(kb.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil)}, public, private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate a key pair on the token: %s", err.Error())
+	}
+
+	info, err := kb.info(pub)
+	if err != nil {
+		kb.ctx.DestroyObject(kb.session, priv)
+		kb.ctx.DestroyObject(kb.session, pub)
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// Update is a no-op, the token only knows the PIN and passphrases are kept by the keyserver
+func (kb *pkcs11Keybase) Update(name, oldpass string, getNewpass func() (string, error)) error {
+	_, err := kb.Get(name)
+	return err
+}
+
+// Delete destroys both keys of the pair on the token
+func (kb *pkcs11Keybase) Delete(name, passphrase string, skipPass bool) error {
+	for _, class := range []uint{pkcs11.CKO_PRIVATE_KEY, pkcs11.CKO_PUBLIC_KEY} {
+		handle, err := kb.findKey(name, class)
+		if err != nil {
+			return err
+		}
+
+		if err := kb.ctx.DestroyObject(kb.session, handle); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Sign signs the sha256 hash of msg on the token, like a secp256k1 key of the other backends
+func (kb *pkcs11Keybase) Sign(name, passphrase string, msg []byte) ([]byte, crypto.PubKey, error) {
+	info, err := kb.Get(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	handle, err := kb.findKey(name, pkcs11.CKO_PRIVATE_KEY)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := kb.ctx.SignInit(kb.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}, handle); err != nil {
+		return nil, nil, err
+	}
+
+	hash := sha256.Sum256(msg)
+	sig, err := kb.ctx.Sign(kb.session, hash[:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sig, err = normalizeSignature(sig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sig, info.GetPubKey(), nil
+}
+
+// ExportPrivateKeyObject fails, private keys never leave the token
+func (kb *pkcs11Keybase) ExportPrivateKeyObject(name, passphrase string) (crypto.PrivKey, error) {
+	if _, err := kb.Get(name); err != nil {
+		return nil, err
+	}
+
+	return nil, errKeyOnToken
+}
+
+// CloseDB logs out and unloads the library
+func (kb *pkcs11Keybase) CloseDB() {
+	kb.ctx.Logout(kb.session)
+	kb.ctx.CloseSession(kb.session)
+	kb.ctx.Finalize()
+	kb.ctx.Destroy()
+}
+
+// pubKeyFromECPoint returns the compressed secp256k1 public key of a CKA_EC_POINT value,
+// which is a DER octet string of the uncompressed point. Some tokens leave out the octet string.
+func pubKeyFromECPoint(point []byte) (crypto.PubKey, error) {
+	if len(point) != 65 || point[0] != 0x04 {
+		var raw []byte
+		if _, err := asn1.Unmarshal(point, &raw); err != nil {
+			return nil, err
+		}
+		point = raw
+	}
+
+	pub, err := btcec.ParsePubKey(point, btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+
+	var pubKey secp256k1.PubKeySecp256k1
+	copy(pubKey[:], pub.SerializeCompressed())
+	return pubKey, nil
+}
+
+// normalizeSignature checks the r || s signature of the token and lowers s into the lower
+// half of the curve order, tendermint rejects signatures with a high s
+func normalizeSignature(sig []byte) ([]byte, error) {
+	if len(sig) != 64 {
+		return nil, fmt.Errorf("expected a 64 byte signature from the token, got %d bytes", len(sig))
+	}
+
+	order := btcec.S256().N
+	s := new(big.Int).SetBytes(sig[32:])
+	if s.Cmp(new(big.Int).Rsh(order, 1)) > 0 {
+		s.Sub(order, s)
+	}
+
+	out := make([]byte, 64)
+	copy(out, sig[:32])
+	sBytes := s.Bytes()
+	copy(out[64-len(sBytes):], sBytes)
+	return out, nil
+}
+
+// pkcs11Info is the public information of a key on the token. It is listed with the ledger
+// type, which cosmos-sdk uses for keys held by a hardware device.
+type pkcs11Info struct {
+	Name   string
+	PubKey crypto.PubKey
+}
+
+var _ ckeys.Info = &pkcs11Info{}
+
+// GetType - nolint
+func (i pkcs11Info) GetType() ckeys.KeyType {
+	return ckeys.TypeLedger
+}
+
+// GetName - nolint
+func (i pkcs11Info) GetName() string {
+	return i.Name
+}
+
+// GetPubKey - nolint
+func (i pkcs11Info) GetPubKey() crypto.PubKey {
+	return i.PubKey
+}
+
+// GetAddress - nolint
+func (i pkcs11Info) GetAddress() sdk.AccAddress {
+	return i.PubKey.Address().Bytes()
+}
+
+// GetPath - nolint
+func (i pkcs11Info) GetPath() (*hd.BIP44Params, error) {
+	return nil, fmt.Errorf("BIP44 Paths are not available for keys on a pkcs11 token")
+}
+
+// GetAlgo - nolint
+func (i pkcs11Info) GetAlgo() ckeys.SigningAlgo {
+	return ckeys.Secp256k1
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	ckeys "github.com/cosmos/cosmos-sdk/crypto/keys"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+)
+
+func TestPubKeyFromECPoint(t *testing.T) {
+	priv := secp256k1.GenPrivKey()
+	pub, err := btcec.ParsePubKey(priv.PubKey().Bytes()[5:], btcec.S256())
+	require.NoError(t, err)
+
+	point := pub.SerializeUncompressed()
+	der, err := asn1.Marshal(point)
+	require.NoError(t, err)
+
+	// test both the octet string of the spec and the bare point
+	for _, value := range [][]byte{der, point} {
+		pubKey, err := pubKeyFromECPoint(value)
+		require.NoError(t, err)
+		require.Equal(t, priv.PubKey(), pubKey)
+	}
+
+	_, err = pubKeyFromECPoint([]byte{0x04, 0x01})
+	require.Error(t, err)
+}
+
+func TestNormalizeSignature(t *testing.T) {
+	priv := secp256k1.GenPrivKey()
+	msg := []byte("message")
+
+	sig, err := priv.Sign(msg)
+	require.NoError(t, err)
+	require.True(t, priv.PubKey().VerifyBytes(msg, sig))
+
+	// test a signature with a high s, as a token may return it, is lowered
+	s := new(big.Int).Sub(btcec.S256().N, new(big.Int).SetBytes(sig[32:]))
+	high := make([]byte, 64)
+	copy(high, sig[:32])
+	copy(high[64-len(s.Bytes()):], s.Bytes())
+	require.False(t, priv.PubKey().VerifyBytes(msg, high))
+
+	normalized, err := normalizeSignature(high)
+	require.NoError(t, err)
+	require.Equal(t, sig, normalized)
+
+	normalized, err = normalizeSignature(sig)
+	require.NoError(t, err)
+	require.Equal(t, sig, normalized)
+
+	_, err = normalizeSignature(sig[:63])
+	require.Error(t, err)
+}
+
+// TestPKCS11Backend runs against a token initialized with SoftHSM2, see the README
+func TestPKCS11Backend(t *testing.T) {
+	library := os.Getenv("KEYSERVER_TEST_PKCS11_LIBRARY")
+	if library == "" {
+		t.Skip("KEYSERVER_TEST_PKCS11_LIBRARY is not set")
+	}
+
+	slot, err := strconv.ParseUint(os.Getenv("KEYSERVER_TEST_PKCS11_SLOT"), 10, 64)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := &Server{KeyDir: dir, KeyringBackend: KeyringBackendPKCS11, PKCS11Library: library, PKCS11Slot: uint(slot), PKCS11PIN: os.Getenv("KEYSERVER_TEST_PKCS11_PIN")}
+	require.NoError(t, s.OpenKeybase())
+	server := httptest.NewServer(s.Router())
+	defer server.Close()
+	defer s.Close()
+
+	// test a mnemonic can't be imported
+	addNP := AddNewKey{Name: testKey, Password: testPass, Mnemonic: sMenominc}
+	postRoute(t, fmt.Sprintf("%s/keys", server.URL), addNP.Marshal(), 400)
+
+	// test the key is generated on the token, without a mnemonic
+	addNP.Mnemonic = ""
+	key := unmarshalKeyOutput(postRoute(t, fmt.Sprintf("%s/keys", server.URL), addNP.Marshal(), 200))
+	require.Empty(t, key.Mnemonic)
+	postRoute(t, fmt.Sprintf("%s/keys", server.URL), addNP.Marshal(), 400)
+	defer s.keybase.Delete(testKey, testPass, true)
+
+	require.Equal(t, key, unmarshalKeyOutput(getRoute(t, fmt.Sprintf("%s/keys/%s", server.URL, testKey), 200)))
+
+	var keys []ckeys.KeyOutput
+	require.NoError(t, json.Unmarshal(getRoute(t, fmt.Sprintf("%s/keys", server.URL), 200), &keys))
+	require.Contains(t, keys, key)
+
+	// test the signature of the token verifies against the public key of the key
+	sender, err := sdk.AccAddressFromBech32(key.Address)
+	require.NoError(t, err)
+	coins := sdk.NewCoins(sdk.NewInt64Coin("uluna", 1000))
+	unsignedTx := auth.NewStdTx([]sdk.Msg{bank.NewMsgSend(sender, sender, coins)}, auth.NewStdFee(200000, coins), nil, "")
+
+	signBody := SignBody{Tx: cdc.MustMarshalJSON(unsignedTx), Name: testKey, Passphrase: testPass, ChainID: "testing", AccountNumber: "3", Sequence: "7"}
+	var signedTx auth.StdTx
+	require.NoError(t, cdc.UnmarshalJSON(postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), signBody.Marshal(), 200), &signedTx))
+	require.Len(t, signedTx.Signatures, 1)
+
+	stdSign, _, err := signBody.StdSignMsg()
+	require.NoError(t, err)
+	sig := signedTx.Signatures[0]
+	require.Equal(t, sender, sdk.AccAddress(sig.PubKey.Address()))
+	require.True(t, sig.PubKey.VerifyBytes(sdk.MustSortJSON(cdc.MustMarshalJSON(stdSign)), sig.Signature))
+
+	signBody.Passphrase = testPassAlt
+	postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), signBody.Marshal(), 500)
+
+	// test deleting removes the key from the token
+	deleteKey := DeleteKeyBody{Password: testPass}
+	deleteRoute(t, fmt.Sprintf("%s/keys/%s", server.URL, testKey), deleteKey.Marshal(), 200)
+	getRoute(t, fmt.Sprintf("%s/keys/%s", server.URL, testKey), 404)
+}
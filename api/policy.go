@@ -0,0 +1,407 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+
+	"github.com/terra-project/core/x/distribution"
+	"github.com/terra-project/core/x/gov"
+	marketexported "github.com/terra-project/core/x/market/exported"
+	msgauthexported "github.com/terra-project/core/x/msgauth/exported"
+	"github.com/terra-project/core/x/oracle"
+	"github.com/terra-project/core/x/slashing"
+	"github.com/terra-project/core/x/staking"
+	"github.com/terra-project/core/x/wasm"
+	wasmexported "github.com/terra-project/core/x/wasm/exported"
+)
+
+// spendWindow is the window of the rolling daily spend limit
+const spendWindow = 24 * time.Hour
+
+// SigningPolicy restricts what the keyserver signs with a key. Empty fields don't restrict.
+type SigningPolicy struct {
+	// Key is the name of the key the policy applies to
+	Key string `json:"key"`
+	// MsgTypes are the amino names of the msgs the key may sign, e.g. bank/MsgSend
+	MsgTypes []string `json:"msgtypes"`
+	// AllowedRecipients are the only addresses the key may send coins to, with them or with
+	// DeniedRecipients msgs the policy can't tell the recipients of are refused
+	AllowedRecipients []string `json:"allowedrecipients"`
+	// DeniedRecipients are addresses the key must not send coins to
+	DeniedRecipients []string `json:"deniedrecipients"`
+	// MaxSpend limits the coins a transaction spends, per listed denom
+	MaxSpend string `json:"maxspend"`
+	// DailySpendLimit limits the coins the transactions signed in the last 24h spend, per listed denom
+	DailySpendLimit string `json:"dailyspendlimit"`
+	// MaxFee limits the fee of a transaction, per listed denom
+	MaxFee string `json:"maxfee"`
+}
+
+// spend is the coins a signed transaction spends
+type spend struct {
+	At    time.Time `json:"at"`
+	Coins sdk.Coins `json:"coins"`
+}
+
+// keyPolicy is a parsed SigningPolicy with the spends of the key in the rolling window
+type keyPolicy struct {
+	msgTypes   map[string]bool
+	allowed    map[string]bool
+	denied     map[string]bool
+	maxSpend   sdk.Coins
+	dailyLimit sdk.Coins
+	maxFee     sdk.Coins
+
+	spends []*spend
+}
+
+// policyEngine evaluates the signing policies of the keys. The spends in the rolling window
+// are kept in the file at path, so the daily limits hold across restarts.
+type policyEngine struct {
+	mtx      sync.Mutex
+	path     string
+	policies map[string]*keyPolicy
+}
+
+// newPolicyEngine parses the policies and loads the spends from the file at path, an empty
+// path keeps them in memory only
+func newPolicyEngine(policies []SigningPolicy, path string) (*policyEngine, error) {
+	pe := &policyEngine{path: path, policies: make(map[string]*keyPolicy)}
+	for _, policy := range policies {
+		if policy.Key == "" {
+			return nil, fmt.Errorf("signing policy without key")
+		}
+		if _, ok := pe.policies[policy.Key]; ok {
+			return nil, fmt.Errorf("signing policy for key %s is configured twice", policy.Key)
+		}
+		if len(policy.AllowedRecipients) > 0 && len(policy.DeniedRecipients) > 0 {
+			return nil, fmt.Errorf("signing policy for key %s has both allowed and denied recipients", policy.Key)
+		}
+
+		kp, err := parsePolicy(policy)
+		if err != nil {
+			return nil, fmt.Errorf("signing policy for key %s: %s", policy.Key, err.Error())
+		}
+		pe.policies[policy.Key] = kp
+	}
+
+	if err := pe.load(); err != nil {
+		return nil, err
+	}
+
+	return pe, nil
+}
+
+// load reads the spends of the keys with a policy, spends that left the window are dropped
+func (pe *policyEngine) load() error {
+	if pe.path == "" {
+		return nil
+	}
+
+	bz, err := ioutil.ReadFile(pe.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var spends map[string][]*spend
+	if err := json.Unmarshal(bz, &spends); err != nil {
+		return fmt.Errorf("failed to read policy spends from %s: %s", pe.path, err.Error())
+	}
+
+	now := time.Now()
+	for name, kp := range pe.policies {
+		kp.spends = spends[name]
+		kp.prune(now)
+	}
+
+	return nil
+}
+
+// save writes the spends of the keys, replacing the file at once
+func (pe *policyEngine) save() error {
+	if pe.path == "" {
+		return nil
+	}
+
+	spends := make(map[string][]*spend)
+	for name, kp := range pe.policies {
+		if len(kp.spends) > 0 {
+			spends[name] = kp.spends
+		}
+	}
+
+	bz, err := json.Marshal(spends)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(pe.path), 0700); err != nil {
+		return err
+	}
+
+	tmp := pe.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, bz, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, pe.path)
+}
+
+func parsePolicy(policy SigningPolicy) (kp *keyPolicy, err error) {
+	kp = &keyPolicy{msgTypes: make(map[string]bool), allowed: make(map[string]bool), denied: make(map[string]bool)}
+	for _, msgType := range policy.MsgTypes {
+		kp.msgTypes[msgType] = true
+	}
+
+	for _, recipient := range policy.AllowedRecipients {
+		addr, err := sdk.AccAddressFromBech32(recipient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed recipient %s: %s", recipient, err.Error())
+		}
+		kp.allowed[addr.String()] = true
+	}
+
+	for _, recipient := range policy.DeniedRecipients {
+		addr, err := sdk.AccAddressFromBech32(recipient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid denied recipient %s: %s", recipient, err.Error())
+		}
+		kp.denied[addr.String()] = true
+	}
+
+	if kp.maxSpend, err = sdk.ParseCoins(policy.MaxSpend); err != nil {
+		return nil, fmt.Errorf("invalid maxspend: %s", err.Error())
+	}
+
+	if kp.dailyLimit, err = sdk.ParseCoins(policy.DailySpendLimit); err != nil {
+		return nil, fmt.Errorf("invalid dailyspendlimit: %s", err.Error())
+	}
+
+	if kp.maxFee, err = sdk.ParseCoins(policy.MaxFee); err != nil {
+		return nil, fmt.Errorf("invalid maxfee: %s", err.Error())
+	}
+
+	return kp, nil
+}
+
+// check evaluates the policy of the key against the transaction to sign. The spend of an
+// allowed transaction counts against the daily limit until release gives it back, which
+// the caller does when signing fails.
+func (pe *policyEngine) check(name string, stdSign auth.StdSignMsg) (release func(), err error) {
+	release = func() {}
+	if pe == nil {
+		return release, nil
+	}
+
+	pe.mtx.Lock()
+	defer pe.mtx.Unlock()
+
+	kp, ok := pe.policies[name]
+	if !ok {
+		return release, nil
+	}
+
+	if len(kp.msgTypes) > 0 {
+		for _, msgType := range msgTypes(stdSign.Msgs) {
+			if !kp.msgTypes[msgType] {
+				return release, fmt.Errorf("policy of key %s doesn't allow signing %s", name, msgType)
+			}
+		}
+	}
+
+	if len(kp.allowed) > 0 || len(kp.denied) > 0 {
+		addrs, err := recipients(stdSign.Msgs)
+		if err != nil {
+			return release, fmt.Errorf("policy of key %s restricts recipients: %s", name, err.Error())
+		}
+
+		for _, recipient := range addrs {
+			if len(kp.allowed) > 0 && !kp.allowed[recipient.String()] {
+				return release, fmt.Errorf("policy of key %s doesn't allow sending to %s", name, recipient)
+			}
+			if kp.denied[recipient.String()] {
+				return release, fmt.Errorf("policy of key %s denies sending to %s", name, recipient)
+			}
+		}
+	}
+
+	if over := exceeded(stdSign.Fee.Amount, kp.maxFee); over != "" {
+		return release, fmt.Errorf("fee of %s exceeds the max fee %s of key %s in %s", stdSign.Fee.Amount, kp.maxFee, name, over)
+	}
+
+	spent := spends(stdSign.Msgs)
+	if over := exceeded(spent, kp.maxSpend); over != "" {
+		return release, fmt.Errorf("transaction spends %s, more than the max spend %s of key %s in %s", spent, kp.maxSpend, name, over)
+	}
+
+	if kp.dailyLimit.Empty() || spent.Empty() {
+		return release, nil
+	}
+
+	now := time.Now()
+	kp.prune(now)
+
+	daily := spent
+	for _, sp := range kp.spends {
+		daily = daily.Add(sp.Coins...)
+	}
+	if over := exceeded(daily, kp.dailyLimit); over != "" {
+		return release, fmt.Errorf("transaction would bring the spend of key %s in 24h to %s, more than the daily spend limit %s in %s", name, daily, kp.dailyLimit, over)
+	}
+
+	sp := &spend{At: now, Coins: spent}
+	kp.spends = append(kp.spends, sp)
+	if err := pe.save(); err != nil {
+		kp.spends = kp.spends[:len(kp.spends)-1]
+		return release, fmt.Errorf("failed to record the spend of key %s: %s", name, err.Error())
+	}
+
+	return func() { pe.release(kp, sp) }, nil
+}
+
+// release removes the spend of a transaction that wasn't signed
+func (pe *policyEngine) release(kp *keyPolicy, sp *spend) {
+	pe.mtx.Lock()
+	defer pe.mtx.Unlock()
+
+	for i, s := range kp.spends {
+		if s == sp {
+			kp.spends = append(kp.spends[:i], kp.spends[i+1:]...)
+			// a spend left in the file only counts against the limit for longer
+			pe.save()
+			return
+		}
+	}
+}
+
+// prune drops the spends that left the rolling window
+func (kp *keyPolicy) prune(now time.Time) {
+	i := 0
+	for i < len(kp.spends) && now.Sub(kp.spends[i].At) >= spendWindow {
+		i++
+	}
+	kp.spends = kp.spends[i:]
+}
+
+// exceeded returns the first denom of limit that coins exceed, coins of denoms missing from
+// limit aren't limited
+func exceeded(coins, limit sdk.Coins) string {
+	for _, max := range limit {
+		if coins.AmountOf(max.Denom).GT(max.Amount) {
+			return max.Denom
+		}
+	}
+
+	return ""
+}
+
+// msgTypes returns the amino names of msgs and of the msgs they execute
+func msgTypes(msgs []sdk.Msg) (types []string) {
+	for _, msg := range msgs {
+		var typed struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(cdc.MustMarshalJSON(msg), &typed); err != nil || typed.Type == "" {
+			typed.Type = msg.Type()
+		}
+		types = append(types, typed.Type)
+
+		if exec, ok := msg.(msgauthexported.MsgExecAuthorized); ok {
+			types = append(types, msgTypes(exec.Msgs)...)
+		}
+	}
+
+	return types
+}
+
+// recipients returns the addresses msgs send coins to or let spend the coins of the key. It
+// fails on msgs sending coins to an address that isn't known before signing, and on msgs
+// it doesn't know whether they move coins.
+func recipients(msgs []sdk.Msg) (addrs []sdk.AccAddress, err error) {
+	for _, msg := range msgs {
+		switch msg := msg.(type) {
+		case bank.MsgSend:
+			addrs = append(addrs, msg.ToAddress)
+		case bank.MsgMultiSend:
+			for _, output := range msg.Outputs {
+				addrs = append(addrs, output.Address)
+			}
+		case marketexported.MsgSwapSend:
+			addrs = append(addrs, msg.ToAddress)
+		case wasmexported.MsgInstantiateContract:
+			if !msg.InitCoins.Empty() {
+				return nil, fmt.Errorf("%s sends coins to a contract without an address before it is signed", msgTypes([]sdk.Msg{msg})[0])
+			}
+		case wasmexported.MsgExecuteContract:
+			if !msg.Coins.Empty() {
+				addrs = append(addrs, msg.Contract)
+			}
+		case distribution.MsgSetWithdrawAddress:
+			addrs = append(addrs, msg.WithdrawAddress)
+		case msgauthexported.MsgGrantAuthorization:
+			addrs = append(addrs, msg.Grantee)
+		case msgauthexported.MsgExecAuthorized:
+			executed, err := recipients(msg.Msgs)
+			if err != nil {
+				return nil, err
+			}
+			addrs = append(addrs, executed...)
+		case marketexported.MsgSwap,
+			gov.MsgSubmitProposal, gov.MsgDeposit, gov.MsgVote,
+			staking.MsgCreateValidator, staking.MsgEditValidator, staking.MsgDelegate, staking.MsgBeginRedelegate, staking.MsgUndelegate,
+			distribution.MsgWithdrawDelegatorReward, distribution.MsgWithdrawValidatorCommission,
+			slashing.MsgUnjail,
+			oracle.MsgExchangeRatePrevote, oracle.MsgExchangeRateVote, oracle.MsgDelegateFeedConsent,
+			oracle.MsgAggregateExchangeRatePrevote, oracle.MsgAggregateExchangeRateVote,
+			msgauthexported.MsgRevokeAuthorization,
+			wasmexported.MsgStoreCode, wasm.MsgMigrateContract, wasm.MsgUpdateContractOwner:
+			// send no coins to another address
+		default:
+			return nil, fmt.Errorf("%s isn't known to send no coins to another address", msgTypes([]sdk.Msg{msg})[0])
+		}
+	}
+
+	return addrs, nil
+}
+
+// spends returns the coins msgs take out of the accounts, delegations stay with the delegator
+func spends(msgs []sdk.Msg) sdk.Coins {
+	coins := sdk.NewCoins()
+	for _, msg := range msgs {
+		switch msg := msg.(type) {
+		case bank.MsgSend:
+			coins = coins.Add(msg.Amount...)
+		case bank.MsgMultiSend:
+			for _, input := range msg.Inputs {
+				coins = coins.Add(input.Coins...)
+			}
+		case marketexported.MsgSwap:
+			coins = coins.Add(msg.OfferCoin)
+		case marketexported.MsgSwapSend:
+			coins = coins.Add(msg.OfferCoin)
+		case wasmexported.MsgInstantiateContract:
+			coins = coins.Add(msg.InitCoins...)
+		case wasmexported.MsgExecuteContract:
+			coins = coins.Add(msg.Coins...)
+		case gov.MsgSubmitProposal:
+			coins = coins.Add(msg.InitialDeposit...)
+		case gov.MsgDeposit:
+			coins = coins.Add(msg.Amount...)
+		case msgauthexported.MsgExecAuthorized:
+			coins = coins.Add(spends(msg.Msgs)...)
+		}
+	}
+
+	return coins
+}
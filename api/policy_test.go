@@ -0,0 +1,208 @@
+package api
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	"github.com/cosmos/cosmos-sdk/x/staking"
+	"github.com/stretchr/testify/require"
+
+	"github.com/terra-project/core/x/distribution"
+	"github.com/terra-project/core/x/gov"
+	"github.com/terra-project/core/x/market"
+	"github.com/terra-project/core/x/msgauth"
+	"github.com/terra-project/core/x/wasm"
+)
+
+func TestSigningPolicy(t *testing.T) {
+	sender, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+	valAddr, err := sdk.ValAddressFromBech32(sVal)
+	require.NoError(t, err)
+	friend := sdk.AccAddress([]byte("friend______________"))
+	stranger := sdk.AccAddress([]byte("stranger____________"))
+
+	pe, err := newPolicyEngine([]SigningPolicy{
+		{
+			Key:               testKey,
+			MsgTypes:          []string{"bank/MsgSend", "market/MsgSwapSend", "staking/MsgDelegate", "gov/MsgSubmitProposal", "msgauth/MsgExecAuthorized"},
+			AllowedRecipients: []string{friend.String()},
+			MaxSpend:          "1000uluna",
+			DailySpendLimit:   "2500uluna",
+			MaxFee:            "100uluna",
+		},
+		{Key: "jim", DeniedRecipients: []string{stranger.String()}},
+	}, "")
+	require.NoError(t, err)
+
+	signMsg := func(fee string, msgs ...sdk.Msg) auth.StdSignMsg {
+		coins, err := sdk.ParseCoins(fee)
+		require.NoError(t, err)
+		return auth.StdSignMsg{ChainID: "testing", Msgs: msgs, Fee: auth.NewStdFee(200000, coins)}
+	}
+	uluna := func(amount int64) sdk.Coins { return sdk.NewCoins(sdk.NewInt64Coin("uluna", amount)) }
+
+	// test keys without a policy sign anything
+	_, err = pe.check("nopolicy", signMsg("1000000uluna", bank.NewMsgSend(sender, stranger, uluna(1000000))))
+	require.NoError(t, err)
+
+	// test msg types
+	_, err = pe.check(testKey, signMsg("10uluna", staking.NewMsgDelegate(sender, valAddr, sdk.NewInt64Coin("uluna", 5000))))
+	require.NoError(t, err)
+	_, err = pe.check(testKey, signMsg("10uluna", market.NewMsgSwap(sender, sdk.NewInt64Coin("uluna", 10), "uusd")))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "market/MsgSwap")
+
+	// test recipients, also in msgs executed on behalf of a granter
+	_, err = pe.check(testKey, signMsg("10uluna", bank.NewMsgSend(sender, stranger, uluna(10))))
+	require.Error(t, err)
+	_, err = pe.check(testKey, signMsg("10uluna", market.NewMsgSwapSend(sender, stranger, sdk.NewInt64Coin("uluna", 10), "uusd")))
+	require.Error(t, err)
+	_, err = pe.check(testKey, signMsg("10uluna", msgauth.NewMsgExecAuthorized(sender, []sdk.Msg{bank.NewMsgSend(friend, stranger, uluna(10))})))
+	require.Error(t, err)
+	_, err = pe.check("jim", signMsg("10uluna", bank.NewMsgSend(sender, stranger, uluna(10))))
+	require.Error(t, err)
+	_, err = pe.check("jim", signMsg("10uluna", bank.NewMsgSend(sender, friend, uluna(10))))
+	require.NoError(t, err)
+
+	// test msgs letting another address spend or receive coins are checked, and msgs sending
+	// coins to an address the policy can't know are refused
+	_, err = pe.check("jim", signMsg("10uluna", msgauth.NewMsgGrantAuthorization(sender, stranger, msgauth.NewSendAuthorization(uluna(10)), time.Hour)))
+	require.Error(t, err)
+	_, err = pe.check("jim", signMsg("10uluna", msgauth.NewMsgGrantAuthorization(sender, friend, msgauth.NewSendAuthorization(uluna(10)), time.Hour)))
+	require.NoError(t, err)
+	_, err = pe.check("jim", signMsg("10uluna", distribution.NewMsgSetWithdrawAddress(sender, stranger)))
+	require.Error(t, err)
+	_, err = pe.check("jim", signMsg("10uluna", distribution.NewMsgSetWithdrawAddress(sender, friend)))
+	require.NoError(t, err)
+	_, err = pe.check("jim", signMsg("10uluna", wasm.NewMsgInstantiateContract(sender, 1, []byte("{}"), uluna(10), false)))
+	require.Error(t, err)
+	_, err = pe.check("jim", signMsg("10uluna", wasm.NewMsgInstantiateContract(sender, 1, []byte("{}"), nil, false)))
+	require.NoError(t, err)
+	_, err = pe.check("jim", signMsg("10uluna", distrtypes.NewMsgFundCommunityPool(uluna(10), sender)))
+	require.Error(t, err)
+	_, err = pe.check("nopolicy", signMsg("10uluna", distrtypes.NewMsgFundCommunityPool(uluna(10), sender)))
+	require.NoError(t, err)
+
+	// test the max fee and the max spend, other denoms aren't limited
+	_, err = pe.check(testKey, signMsg("101uluna", bank.NewMsgSend(sender, friend, uluna(10))))
+	require.Error(t, err)
+	_, err = pe.check(testKey, signMsg("10uluna", bank.NewMsgSend(sender, friend, uluna(600)), bank.NewMsgSend(sender, friend, uluna(600))))
+	require.Error(t, err)
+	_, err = pe.check(testKey, signMsg("1000000ukrw", bank.NewMsgSend(sender, friend, sdk.NewCoins(sdk.NewInt64Coin("ukrw", 1000000)))))
+	require.NoError(t, err)
+
+	// test the initial deposit of a proposal is spent
+	proposal := gov.NewMsgSubmitProposal(gov.NewTextProposal("title", "description"), uluna(1001), sender)
+	_, err = pe.check(testKey, signMsg("10uluna", proposal))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "max spend")
+
+	// test the daily spend limit, a released spend doesn't count
+	_, err = pe.check(testKey, signMsg("10uluna", bank.NewMsgSend(sender, friend, uluna(1000))))
+	require.NoError(t, err)
+	release, err := pe.check(testKey, signMsg("10uluna", bank.NewMsgSend(sender, friend, uluna(1000))))
+	require.NoError(t, err)
+	_, err = pe.check(testKey, signMsg("10uluna", bank.NewMsgSend(sender, friend, uluna(1000))))
+	require.Error(t, err)
+	release()
+	_, err = pe.check(testKey, signMsg("10uluna", bank.NewMsgSend(sender, friend, uluna(1000))))
+	require.NoError(t, err)
+	_, err = pe.check(testKey, signMsg("10uluna", bank.NewMsgSend(sender, friend, uluna(600))))
+	require.Error(t, err)
+
+	// test spends leave the rolling window after 24h
+	kp := pe.policies[testKey]
+	require.Len(t, kp.spends, 3)
+	kp.spends[0].At = time.Now().Add(-spendWindow)
+	kp.spends[1].At = time.Now().Add(-spendWindow)
+	_, err = pe.check(testKey, signMsg("10uluna", bank.NewMsgSend(sender, friend, uluna(600))))
+	require.NoError(t, err)
+	require.Len(t, kp.spends, 2)
+}
+
+func TestSigningPolicySpendsPersist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "policy-spends.json")
+
+	sender, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+	uluna := func(amount int64) sdk.Coins { return sdk.NewCoins(sdk.NewInt64Coin("uluna", amount)) }
+	send := func(amount int64) auth.StdSignMsg {
+		return auth.StdSignMsg{ChainID: "testing", Msgs: []sdk.Msg{bank.NewMsgSend(sender, sender, uluna(amount))}, Fee: auth.NewStdFee(200000, uluna(10))}
+	}
+	policies := []SigningPolicy{{Key: testKey, DailySpendLimit: "2500uluna"}}
+
+	pe, err := newPolicyEngine(policies, path)
+	require.NoError(t, err)
+	_, err = pe.check(testKey, send(1000))
+	require.NoError(t, err)
+	_, err = pe.check(testKey, send(1000))
+	require.NoError(t, err)
+	release, err := pe.check(testKey, send(500))
+	require.NoError(t, err)
+	release()
+
+	// test the spends hold the daily limit after a restart, a released spend doesn't count
+	pe, err = newPolicyEngine(policies, path)
+	require.NoError(t, err)
+	require.Len(t, pe.policies[testKey].spends, 2)
+	_, err = pe.check(testKey, send(600))
+	require.Error(t, err)
+	_, err = pe.check(testKey, send(500))
+	require.NoError(t, err)
+
+	// test spends that left the window are dropped on load
+	pe.policies[testKey].spends[0].At = time.Now().Add(-spendWindow)
+	require.NoError(t, pe.save())
+	pe, err = newPolicyEngine(policies, path)
+	require.NoError(t, err)
+	require.Len(t, pe.policies[testKey].spends, 2)
+}
+
+func TestSigningPolicyConfig(t *testing.T) {
+	_, err := newPolicyEngine([]SigningPolicy{{}}, "")
+	require.Error(t, err)
+
+	_, err = newPolicyEngine([]SigningPolicy{{Key: testKey}, {Key: testKey}}, "")
+	require.Error(t, err)
+
+	_, err = newPolicyEngine([]SigningPolicy{{Key: testKey, AllowedRecipients: []string{sAcc}, DeniedRecipients: []string{sAcc}}}, "")
+	require.Error(t, err)
+
+	_, err = newPolicyEngine([]SigningPolicy{{Key: testKey, AllowedRecipients: []string{"terra1invalid"}}}, "")
+	require.Error(t, err)
+
+	_, err = newPolicyEngine([]SigningPolicy{{Key: testKey, MaxFee: "uluna"}}, "")
+	require.Error(t, err)
+}
+
+func TestSignRejectsPolicyViolations(t *testing.T) {
+	s := &Server{KeyringBackend: KeyringBackendMemory, Policies: []SigningPolicy{{Key: testKey, MaxSpend: "500uluna"}}}
+	server := httptest.NewServer(s.Router())
+	defer server.Close()
+	defer s.Close()
+
+	addNP := AddNewKey{Name: testKey, Password: testPass, Mnemonic: sMenominc}
+	postRoute(t, fmt.Sprintf("%s/keys", server.URL), addNP.Marshal(), 200)
+
+	sender, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+	coins := sdk.NewCoins(sdk.NewInt64Coin("uluna", 1000))
+	unsignedTx := auth.NewStdTx([]sdk.Msg{bank.NewMsgSend(sender, sender, coins)}, auth.NewStdFee(200000, coins), nil, "")
+
+	signBody := SignBody{Tx: cdc.MustMarshalJSON(unsignedTx), Name: testKey, Passphrase: testPass, ChainID: "testing", AccountNumber: "3", Sequence: "7"}
+	out := postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), signBody.Marshal(), 403)
+	require.Contains(t, string(out), "max spend")
+}
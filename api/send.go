@@ -5,14 +5,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"strconv"
 
-	"github.com/cosmos/cosmos-sdk/client/flags"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/x/auth"
 	"github.com/cosmos/cosmos-sdk/x/bank"
-
-	core "github.com/terra-project/core/types"
 )
 
 // BankSendBody contains the necessary data to make a send transaction
@@ -55,132 +51,28 @@ func (s *Server) BankSend(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	coins, err := sdk.ParseCoins(sb.Amount)
+	stdTx, err := s.bankSendTx(sb)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		w.Write(newError(fmt.Errorf("failed to parse amount %s into sdk.Coins", sb.Amount)).marshal())
+		w.Write(newError(err).marshal())
 		return
 	}
 
-	var fees sdk.Coins
-	if sb.Fees != "" {
-		if sb.GasPrices != "" {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(newError(fmt.Errorf("GasPrices and Fees cannot be used at the same time")).marshal())
-			return
-		}
-
-		fees, err = sdk.ParseCoins(sb.Fees)
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(newError(fmt.Errorf("failed to parse fees %s into sdk.Coins", sb.Fees)).marshal())
-			return
-		}
-	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(cdc.MustMarshalJSON(stdTx))
+	return
+}
 
-	// dummy fee & dummy gas limit
-	var feesForSim sdk.Coins
-	if fees.Empty() {
-		feesForSim = sdk.NewCoins(sdk.NewCoin(coins[0].Denom, sdk.NewInt(1)))
-	} else {
-		feesForSim = sdk.NewCoins(fees...)
+// bankSendTx builds an unsigned send transaction from a BankSendBody
+func (s *Server) bankSendTx(sb BankSendBody) (stdTx auth.StdTx, err error) {
+	coins, err := sdk.ParseCoins(sb.Amount)
+	if err != nil {
+		return stdTx, fmt.Errorf("failed to parse amount %s into sdk.Coins", sb.Amount)
 	}
 
-	stdTx := auth.NewStdTx(
+	return s.buildTx(
 		[]sdk.Msg{bank.MsgSend{FromAddress: sb.Sender, ToAddress: sb.Reciever, Amount: coins}},
-		auth.NewStdFee(flags.DefaultGasLimit, feesForSim),
-		[]auth.StdSignature{{}},
 		sb.Memo,
+		FeeOptions{Fees: sb.Fees, Gas: sb.Gas, GasPrices: sb.GasPrices, GasAdjustment: sb.GasAdjustment},
 	)
-
-	var gas uint64
-	if sb.Gas != "" {
-		gas, err = strconv.ParseUint(sb.Gas, 10, 64)
-	} else {
-		gas, err = s.SimulateGas(cdc.MustMarshalBinaryLengthPrefixed(stdTx))
-	}
-
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write(newError(fmt.Errorf("failed to parse gas %s into uint64; %s", sb.Gas, err.Error())).marshal())
-		return
-	}
-
-	if gas != 0 && sb.GasAdjustment != "" {
-		adj, err := strconv.ParseFloat(sb.GasAdjustment, 64)
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(newError(fmt.Errorf("failed to parse gasAdjustment %s into float64", sb.GasAdjustment)).marshal())
-			return
-		}
-		gas = uint64(adj * float64(gas))
-	}
-
-	if sb.GasPrices != "" {
-		gasPrices, err := sdk.ParseDecCoins(sb.GasPrices)
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write(newError(fmt.Errorf("failed to parse gasPrices %s into sdk.DecCoins", sb.GasPrices)).marshal())
-			return
-		}
-
-		for _, gasPrice := range gasPrices {
-			fee := sdk.NewCoin(gasPrice.Denom, gasPrice.Amount.MulInt64(int64(gas)).Ceil().TruncateInt())
-			fees = append(fees, fee)
-		}
-
-		fees = fees.Sort()
-	}
-
-	if sb.Fees == "" {
-		// Compute Tax
-		taxRate, err := s.LoadTaxRate()
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-
-			w.Write([]byte(fmt.Sprintf("failed to load tax rate: %s", err.Error())))
-			return
-		}
-
-		var taxes sdk.Coins
-		for _, coin := range coins {
-			if coin.Denom == core.MicroLunaDenom {
-				continue
-			}
-
-			taxCap, err := s.LoadTaxCap(coin.Denom)
-			if err != nil {
-				w.WriteHeader(http.StatusBadRequest)
-
-				w.Write([]byte(fmt.Sprintf("failed to load tax cap: %s", err.Error())))
-				return
-			}
-
-			taxDue := taxRate.MulInt(coin.Amount).TruncateInt()
-			if taxDue.GT(taxCap) {
-				taxDue = taxCap
-			}
-
-			taxes = append(taxes, sdk.NewCoin(coin.Denom, taxDue))
-		}
-
-		taxes = taxes.Sort()
-		for coinidx :=0; coinidx < fees.Len(); coinidx ++ {
-			denom := taxes.GetDenomByIndex(coinidx)
-			amount := taxes.AmountOf(denom)
-			fees = fees.Add(sdk.NewCoin(denom, amount))
-		}
-		fees.Sort()
-	}
-
-	stdTx = auth.NewStdTx(
-		stdTx.Msgs,
-		auth.NewStdFee(gas, fees),
-		[]auth.StdSignature{},
-		stdTx.Memo,
-	)
-
-	w.WriteHeader(http.StatusOK)
-	w.Write(cdc.MustMarshalJSON(stdTx))
-	return
 }
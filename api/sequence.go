@@ -0,0 +1,87 @@
+package api
+
+import (
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authexported "github.com/cosmos/cosmos-sdk/x/auth/exported"
+)
+
+// accountLoader loads an account from chain state
+type accountLoader func(address sdk.AccAddress) (authexported.Account, error)
+
+// sequenceManager hands out account sequences per address, so concurrent
+// sign requests for the same key never reuse a sequence
+type sequenceManager struct {
+	mtx      sync.Mutex
+	accounts map[string]*accountSequence
+}
+
+type accountSequence struct {
+	mtx           sync.Mutex
+	seeded        bool
+	accountNumber uint64
+	sequence      uint64
+}
+
+func newSequenceManager() *sequenceManager {
+	return &sequenceManager{accounts: make(map[string]*accountSequence)}
+}
+
+func (sm *sequenceManager) get(address sdk.AccAddress) *accountSequence {
+	sm.mtx.Lock()
+	defer sm.mtx.Unlock()
+
+	acc, ok := sm.accounts[address.String()]
+	if !ok {
+		acc = &accountSequence{}
+		sm.accounts[address.String()] = acc
+	}
+
+	return acc
+}
+
+// Next reserves the next sequence for the address, seeding it from chain state
+// through load when the address is not tracked yet
+func (sm *sequenceManager) Next(address sdk.AccAddress, load accountLoader) (accountNumber uint64, sequence uint64, err error) {
+	acc := sm.get(address)
+	acc.mtx.Lock()
+	defer acc.mtx.Unlock()
+
+	if !acc.seeded {
+		account, err := load(address)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		acc.accountNumber = account.GetAccountNumber()
+		acc.sequence = account.GetSequence()
+		acc.seeded = true
+	}
+
+	sequence = acc.sequence
+	acc.sequence++
+
+	return acc.accountNumber, sequence, nil
+}
+
+// Release gives back a reserved sequence that was never signed with;
+// it only takes effect if no later sequence has been handed out since
+func (sm *sequenceManager) Release(address sdk.AccAddress, sequence uint64) {
+	acc := sm.get(address)
+	acc.mtx.Lock()
+	defer acc.mtx.Unlock()
+
+	if acc.seeded && acc.sequence == sequence+1 {
+		acc.sequence = sequence
+	}
+}
+
+// Reset drops the tracked sequence, so the next reservation is seeded from chain state again
+func (sm *sequenceManager) Reset(address sdk.AccAddress) {
+	acc := sm.get(address)
+	acc.mtx.Lock()
+	defer acc.mtx.Unlock()
+
+	acc.seeded = false
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"sync"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	authexported "github.com/cosmos/cosmos-sdk/x/auth/exported"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequenceManager(t *testing.T) {
+	address, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+
+	loads := 0
+	load := func(addr sdk.AccAddress) (authexported.Account, error) {
+		loads++
+		return auth.NewBaseAccount(addr, nil, nil, 5, 10), nil
+	}
+
+	sm := newSequenceManager()
+
+	// test concurrent reservations never share a sequence
+	var mtx sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[uint64]bool)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			accountNumber, sequence, err := sm.Next(address, load)
+			require.NoError(t, err)
+			require.Equal(t, uint64(5), accountNumber)
+
+			mtx.Lock()
+			defer mtx.Unlock()
+			require.False(t, seen[sequence])
+			seen[sequence] = true
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, 1, loads)
+	for sequence := uint64(10); sequence < 30; sequence++ {
+		require.True(t, seen[sequence])
+	}
+
+	// test releasing the latest sequence hands it out again
+	_, sequence, err := sm.Next(address, load)
+	require.NoError(t, err)
+	require.Equal(t, uint64(30), sequence)
+	sm.Release(address, sequence)
+	_, sequence, err = sm.Next(address, load)
+	require.NoError(t, err)
+	require.Equal(t, uint64(30), sequence)
+
+	// test releasing an older sequence is ignored
+	sm.Release(address, 20)
+	_, sequence, err = sm.Next(address, load)
+	require.NoError(t, err)
+	require.Equal(t, uint64(31), sequence)
+
+	// test reset reseeds from chain state
+	sm.Reset(address)
+	_, sequence, err = sm.Next(address, load)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), sequence)
+	require.Equal(t, 2, loads)
+}
+
+func TestBroadcastResetsSequences(t *testing.T) {
+	address, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+
+	loads := 0
+	load := func(addr sdk.AccAddress) (authexported.Account, error) {
+		loads++
+		return auth.NewBaseAccount(addr, nil, nil, 5, 10), nil
+	}
+
+	results := map[string]string{"broadcast_tx_sync": `{"code":0,"data":"","log":"","codespace":"","hash":"AB"}`}
+	node := fakeNodeMethods(results, nil)
+	defer node.Close()
+
+	s := &Server{KeyringBackend: KeyringBackendMemory, Node: node.URL}
+	s.Router()
+	defer s.Close()
+
+	coins := sdk.NewCoins(sdk.NewInt64Coin("uluna", 1000))
+	stdTx := auth.NewStdTx([]sdk.Msg{bank.NewMsgSend(address, address, coins)}, auth.NewStdFee(200000, coins), nil, "")
+
+	// test a transaction passing CheckTx keeps the tracked sequence
+	_, _, err = s.sequences.Next(address, load)
+	require.NoError(t, err)
+	_, _, err = s.broadcastTx(stdTx, BroadcastSync)
+	require.NoError(t, err)
+	_, sequence, err := s.sequences.Next(address, load)
+	require.NoError(t, err)
+	require.Equal(t, uint64(11), sequence)
+
+	// test any CheckTx failure reseeds the sequence from chain state
+	results["broadcast_tx_sync"] = `{"code":5,"data":"","log":"insufficient funds","codespace":"sdk","hash":"AB"}`
+	res, _, err := s.broadcastTx(stdTx, BroadcastSync)
+	require.NoError(t, err)
+	require.Equal(t, uint32(5), res.Code)
+	_, sequence, err = s.sequences.Next(address, load)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), sequence)
+	require.Equal(t, 2, loads)
+
+	// test a failed broadcast reseeds the sequence from chain state
+	node.Close()
+	_, _, err = s.broadcastTx(stdTx, BroadcastSync)
+	require.Error(t, err)
+	_, sequence, err = s.sequences.Next(address, load)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), sequence)
+	require.Equal(t, 3, loads)
+}
@@ -0,0 +1,392 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/keyerror"
+	"github.com/gorilla/mux"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+)
+
+const (
+	// defaultSessionTTL is how long a key stays unlocked when the unlock request has no ttl
+	defaultSessionTTL = 5 * time.Minute
+	// defaultSessionMaxTTL bounds the ttl of unlock requests when the config doesn't
+	defaultSessionMaxTTL = time.Hour
+)
+
+// errSessionNotFound is returned for tokens that were never issued, are expired, used up or locked
+var errSessionNotFound = fmt.Errorf("session token is unknown or expired, unlock the key again")
+
+// errEmptyPassphrase is returned for unlock requests without a passphrase, a session can't hold
+// an empty secret in locked memory
+var errEmptyPassphrase = fmt.Errorf("passphrase is required to unlock a key")
+
+// session is an unlocked key. It holds the private key, or the passphrase for keys kept on a
+// token, in locked memory that is zeroed once the session ends.
+type session struct {
+	mtx sync.Mutex
+
+	name      string
+	onToken   bool
+	secret    []byte
+	expiresAt time.Time
+	maxUses   int
+	uses      int
+	timer     *time.Timer
+}
+
+// destroy zeroes the secret, the session can't sign anymore
+func (ss *session) destroy() {
+	ss.mtx.Lock()
+	defer ss.mtx.Unlock()
+
+	if ss.secret == nil {
+		return
+	}
+
+	for i := range ss.secret {
+		ss.secret[i] = 0
+	}
+	freeLocked(ss.secret)
+	ss.secret = nil
+}
+
+// sign signs msg with the key of the session
+func (ss *session) sign(kb Keybase, msg []byte) ([]byte, crypto.PubKey, error) {
+	ss.mtx.Lock()
+	defer ss.mtx.Unlock()
+
+	if ss.secret == nil {
+		return nil, nil, errSessionNotFound
+	}
+
+	if ss.onToken {
+		return kb.Sign(ss.name, string(ss.secret), msg)
+	}
+
+	var priv secp256k1.PrivKeySecp256k1
+	copy(priv[:], ss.secret)
+	defer func() {
+		for i := range priv {
+			priv[i] = 0
+		}
+	}()
+
+	sig, err := priv.Sign(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sig, priv.PubKey(), nil
+}
+
+// sessionStore keeps the sessions of unlocked keys by their token
+type sessionStore struct {
+	mtx      sync.Mutex
+	sessions map[string]*session
+}
+
+func newSessionStore() *sessionStore {
+	return &sessionStore{sessions: make(map[string]*session)}
+}
+
+// unlock starts a session for the key, maxUses of 0 doesn't limit the uses
+func (st *sessionStore) unlock(kb Keybase, name, passphrase string, ttl time.Duration, maxUses int) (token string, ss *session, err error) {
+	if passphrase == "" {
+		return "", nil, errEmptyPassphrase
+	}
+
+	ss = &session{name: name, maxUses: maxUses}
+
+	priv, err := kb.ExportPrivateKeyObject(name, passphrase)
+	switch {
+	case err == errKeyOnToken:
+		// the passphrase is right, the token signs with it
+		ss.onToken = true
+		if ss.secret, err = allocLocked(len(passphrase)); err != nil {
+			return "", nil, err
+		}
+		copy(ss.secret, passphrase)
+	case err != nil:
+		return "", nil, err
+	default:
+		key, ok := priv.(secp256k1.PrivKeySecp256k1)
+		if !ok {
+			return "", nil, fmt.Errorf("keys of type %T can't be unlocked", priv)
+		}
+		defer func() {
+			for i := range key {
+				key[i] = 0
+			}
+		}()
+
+		if ss.secret, err = allocLocked(len(key)); err != nil {
+			return "", nil, err
+		}
+		copy(ss.secret, key[:])
+	}
+
+	bz := make([]byte, 32)
+	if _, err := rand.Read(bz); err != nil {
+		ss.destroy()
+		return "", nil, err
+	}
+	token = hex.EncodeToString(bz)
+
+	st.mtx.Lock()
+	defer st.mtx.Unlock()
+
+	ss.expiresAt = time.Now().Add(ttl)
+	st.sessions[token] = ss
+	ss.timer = time.AfterFunc(ttl, func() { st.lock(token) })
+	return token, ss, nil
+}
+
+// get returns the session of the token unless it expired
+func (st *sessionStore) get(token string) (*session, error) {
+	st.mtx.Lock()
+	defer st.mtx.Unlock()
+
+	ss, ok := st.sessions[token]
+	if !ok || time.Now().After(ss.expiresAt) {
+		return nil, errSessionNotFound
+	}
+
+	return ss, nil
+}
+
+// sign signs msg with the key of the token, counting the use of the session
+func (st *sessionStore) sign(kb Keybase, token string, msg []byte) ([]byte, crypto.PubKey, error) {
+	st.mtx.Lock()
+	ss, ok := st.sessions[token]
+	if !ok || time.Now().After(ss.expiresAt) {
+		st.mtx.Unlock()
+		return nil, nil, errSessionNotFound
+	}
+
+	ss.uses++
+	last := ss.maxUses > 0 && ss.uses >= ss.maxUses
+	if last {
+		delete(st.sessions, token)
+		ss.timer.Stop()
+	}
+	st.mtx.Unlock()
+
+	if last {
+		defer ss.destroy()
+	}
+
+	return ss.sign(kb, msg)
+}
+
+// lock ends the session of the token
+func (st *sessionStore) lock(token string) {
+	st.mtx.Lock()
+	ss, ok := st.sessions[token]
+	delete(st.sessions, token)
+	st.mtx.Unlock()
+
+	if ok {
+		ss.timer.Stop()
+		ss.destroy()
+	}
+}
+
+// lockKey ends all sessions of the key
+func (st *sessionStore) lockKey(name string) {
+	var tokens []string
+
+	st.mtx.Lock()
+	for token, ss := range st.sessions {
+		if ss.name == name {
+			tokens = append(tokens, token)
+		}
+	}
+	st.mtx.Unlock()
+
+	for _, token := range tokens {
+		st.lock(token)
+	}
+}
+
+// lockAll ends every session
+func (st *sessionStore) lockAll() {
+	var tokens []string
+
+	st.mtx.Lock()
+	for token := range st.sessions {
+		tokens = append(tokens, token)
+	}
+	st.mtx.Unlock()
+
+	for _, token := range tokens {
+		st.lock(token)
+	}
+}
+
+// UnlockBody is the body for an unlock request
+type UnlockBody struct {
+	Passphrase string `json:"passphrase"`
+	TTL        string `json:"ttl,omitempty"`
+	MaxUses    int    `json:"max_uses,omitempty"`
+}
+
+// Marshal - nolint
+func (ub UnlockBody) Marshal() []byte {
+	out, err := json.Marshal(ub)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// UnlockResponse is the response for an unlock request
+type UnlockResponse struct {
+	Token     string    `json:"token"`
+	Name      string    `json:"name"`
+	ExpiresAt time.Time `json:"expires_at"`
+	MaxUses   int       `json:"max_uses,omitempty"`
+}
+
+// Unlock is the handler for the POST /keys/{name}/unlock
+func (s *Server) Unlock(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	var m UnlockBody
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	err = json.Unmarshal(body, &m)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	maxTTL := s.SessionMaxTTL
+	if maxTTL == 0 {
+		maxTTL = defaultSessionMaxTTL
+	}
+
+	ttl := defaultSessionTTL
+	if ttl > maxTTL {
+		ttl = maxTTL
+	}
+
+	if m.TTL != "" {
+		ttl, err = time.ParseDuration(m.TTL)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(newError(err).marshal())
+			return
+		}
+	}
+
+	if ttl <= 0 || ttl > maxTTL {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("ttl must be positive and at most %s", maxTTL)).marshal())
+		return
+	}
+
+	if m.MaxUses < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("max_uses can't be negative")).marshal())
+		return
+	}
+
+	token, ss, err := s.sessions.unlock(s.keybase, name, m.Passphrase, ttl, m.MaxUses)
+	if err == errEmptyPassphrase {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	} else if keyerror.IsErrKeyNotFound(err) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(newError(err).marshal())
+		return
+	} else if keyerror.IsErrWrongPassword(err) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write(newError(err).marshal())
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	out, err := json.Marshal(UnlockResponse{Token: token, Name: name, ExpiresAt: ss.expiresAt.UTC(), MaxUses: ss.maxUses})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+	return
+}
+
+// LockBody is the body for a lock request, without a token all sessions of the key end
+type LockBody struct {
+	Token string `json:"token,omitempty"`
+}
+
+// Marshal - nolint
+func (lb LockBody) Marshal() []byte {
+	out, err := json.Marshal(lb)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// Lock is the handler for the POST /keys/{name}/lock
+func (s *Server) Lock(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	var m LockBody
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	if len(body) > 0 {
+		err = json.Unmarshal(body, &m)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(newError(err).marshal())
+			return
+		}
+	}
+
+	if m.Token == "" {
+		s.sessions.lockKey(name)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	ss, err := s.sessions.get(m.Token)
+	if err != nil || ss.name != name {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(newError(errSessionNotFound).marshal())
+		return
+	}
+
+	s.sessions.lock(m.Token)
+	w.WriteHeader(http.StatusOK)
+	return
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnlockSessions(t *testing.T) {
+	s := &Server{KeyringBackend: KeyringBackendMemory, SessionMaxTTL: time.Minute}
+	server := httptest.NewServer(s.Router())
+	defer server.Close()
+	defer s.Close()
+
+	addNP := AddNewKey{Name: testKey, Password: testPass, Mnemonic: sMenominc}
+	postRoute(t, fmt.Sprintf("%s/keys", server.URL), addNP.Marshal(), 200)
+
+	unlock := func(ub UnlockBody, expStatus int) (res UnlockResponse) {
+		out := postRoute(t, fmt.Sprintf("%s/keys/%s/unlock", server.URL, testKey), ub.Marshal(), expStatus)
+		if expStatus == 200 {
+			require.NoError(t, json.Unmarshal(out, &res))
+		}
+		return res
+	}
+
+	// test unlocking checks the key, the passphrase and the ttl
+	postRoute(t, fmt.Sprintf("%s/keys/%s/unlock", server.URL, "nokey"), UnlockBody{Passphrase: testPass}.Marshal(), 404)
+	// only the keyring reports a wrong password as such
+	unlock(UnlockBody{Passphrase: testPassAlt}, 500)
+	unlock(UnlockBody{Passphrase: testPass, TTL: "2m"}, 400)
+	unlock(UnlockBody{Passphrase: testPass, TTL: "-1s"}, 400)
+	unlock(UnlockBody{Passphrase: testPass, MaxUses: -1}, 400)
+	unlock(UnlockBody{}, 400)
+
+	sender, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+	coins := sdk.NewCoins(sdk.NewInt64Coin("uluna", 1000))
+	unsignedTx := auth.NewStdTx([]sdk.Msg{bank.NewMsgSend(sender, sender, coins)}, auth.NewStdFee(200000, coins), nil, "")
+
+	// the key signs deterministically, so the session has to give the same transaction
+	signBody := SignBody{Tx: cdc.MustMarshalJSON(unsignedTx), Name: testKey, Passphrase: testPass, ChainID: "testing", AccountNumber: "3", Sequence: "7"}
+	signed := postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), signBody.Marshal(), 200)
+
+	// test a session signs without the passphrase up to max_uses times
+	res := unlock(UnlockBody{Passphrase: testPass, MaxUses: 2}, 200)
+	require.Equal(t, testKey, res.Name)
+	require.Equal(t, 2, res.MaxUses)
+	// the default ttl is capped at the configured max
+	require.WithinDuration(t, time.Now().Add(time.Minute), res.ExpiresAt, 5*time.Second)
+
+	tokenBody := SignBody{Tx: signBody.Tx, Token: res.Token, ChainID: "testing", AccountNumber: "3", Sequence: "7"}
+	require.Equal(t, signed, postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), tokenBody.Marshal(), 200))
+	tokenBody.Name = "jim"
+	postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), tokenBody.Marshal(), 401)
+	tokenBody.Name = testKey
+	require.Equal(t, signed, postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), tokenBody.Marshal(), 200))
+	postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), tokenBody.Marshal(), 401)
+
+	// test locking with the token ends only that session
+	res = unlock(UnlockBody{Passphrase: testPass}, 200)
+	other := unlock(UnlockBody{Passphrase: testPass}, 200)
+	ss, err := s.sessions.get(res.Token)
+	require.NoError(t, err)
+	postRoute(t, fmt.Sprintf("%s/keys/%s/lock", server.URL, testKey), LockBody{Token: res.Token}.Marshal(), 200)
+	requireDestroyed(t, ss)
+	postRoute(t, fmt.Sprintf("%s/keys/%s/lock", server.URL, testKey), LockBody{Token: res.Token}.Marshal(), 404)
+	tokenBody.Token = res.Token
+	postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), tokenBody.Marshal(), 401)
+	tokenBody.Token = other.Token
+	postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), tokenBody.Marshal(), 200)
+
+	// test locking without a token ends every session of the key
+	postRoute(t, fmt.Sprintf("%s/keys/%s/lock", server.URL, testKey), nil, 200)
+	postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), tokenBody.Marshal(), 401)
+
+	// test the session ends after its ttl
+	res = unlock(UnlockBody{Passphrase: testPass, TTL: "500ms"}, 200)
+	ss, err = s.sessions.get(res.Token)
+	require.NoError(t, err)
+	time.Sleep(time.Second)
+	tokenBody.Token = res.Token
+	postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), tokenBody.Marshal(), 401)
+	requireDestroyed(t, ss)
+
+	// test changing the passphrase ends the sessions of the key
+	res = unlock(UnlockBody{Passphrase: testPass}, 200)
+	ss, err = s.sessions.get(res.Token)
+	require.NoError(t, err)
+	updatePass := UpdateKeyBody{OldPassword: testPass, NewPassword: testPassAlt}
+	putRoute(t, fmt.Sprintf("%s/keys/%s", server.URL, testKey), updatePass.Marshal(), 200)
+	tokenBody.Token = res.Token
+	postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), tokenBody.Marshal(), 401)
+	requireDestroyed(t, ss)
+
+	// test deleting the key ends its sessions
+	res = unlock(UnlockBody{Passphrase: testPassAlt}, 200)
+	ss, err = s.sessions.get(res.Token)
+	require.NoError(t, err)
+	deleteRoute(t, fmt.Sprintf("%s/keys/%s", server.URL, testKey), DeleteKeyBody{Password: testPassAlt}.Marshal(), 200)
+	tokenBody.Token = res.Token
+	postRoute(t, fmt.Sprintf("%s/tx/sign", server.URL), tokenBody.Marshal(), 401)
+	requireDestroyed(t, ss)
+}
+
+// requireDestroyed checks the secret of the session was zeroed and freed
+func requireDestroyed(t *testing.T, ss *session) {
+	ss.mtx.Lock()
+	defer ss.mtx.Unlock()
+
+	require.Nil(t, ss.secret)
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/cosmos/cosmos-sdk/x/auth/types"
@@ -8,19 +10,28 @@ import (
 	"net/http"
 	"strconv"
 
-	"github.com/cosmos/cosmos-sdk/client/keys"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/keyerror"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/tendermint/tendermint/crypto"
+)
+
+const (
+	// HeaderAccountNumber is the response header echoing the account number used to sign
+	HeaderAccountNumber = "X-Account-Number"
+	// HeaderSequence is the response header echoing the sequence used to sign
+	HeaderSequence = "X-Sequence"
 )
 
 // SignBody is the body for a sign request
 type SignBody struct {
 	Tx            json.RawMessage `json:"tx"`
 	Name          string          `json:"name"`
-	Passphrase    string          `json:"passphrase"`
+	Passphrase    string          `json:"passphrase,omitempty"`
+	Token         string          `json:"token,omitempty"`
 	ChainID       string          `json:"chain_id"`
-	AccountNumber string          `json:"account_number"`
-	Sequence      string          `json:"sequence"`
+	AccountNumber string          `json:"account_number,omitempty"`
+	Sequence      string          `json:"sequence,omitempty"`
 }
 
 // Marshal returns the json byte representation of the sign body
@@ -69,13 +80,6 @@ func (sb SignBody) StdSignMsg() (stdSign auth.StdSignMsg, stdTx auth.StdTx, err
 func (s *Server) Sign(w http.ResponseWriter, r *http.Request) {
 	var m SignBody
 
-	kb, err := keys.NewKeyBaseFromDir(s.KeyDir)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write(newError(err).marshal())
-		return
-	}
-
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -90,20 +94,123 @@ func (s *Server) Sign(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stdSign, stdTx, err := m.StdSignMsg()
+	signedStdTx, status, err := s.signTx(&m, auditEntry(r))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(status)
 		w.Write(newError(err).marshal())
 		return
 	}
 
-	sigBytes, pubkey, err := kb.Sign(m.Name, m.Passphrase, sdk.MustSortJSON(cdc.MustMarshalJSON(stdSign)))
+	out, err := cdc.MarshalJSON(signedStdTx)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write(newError(err).marshal())
 		return
 	}
 
+	w.Header().Set(HeaderAccountNumber, m.AccountNumber)
+	w.Header().Set(HeaderSequence, m.Sequence)
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+	return
+}
+
+// signTx signs the transaction in the SignBody, filling in the account number
+// and sequence when they are missing, and fills in the audit entry when given. On failure
+// it returns the http status to reply with.
+func (s *Server) signTx(m *SignBody, entry *AuditEntry) (signedStdTx auth.StdTx, status int, err error) {
+	// release gives back a sequence reserved from the sequence manager when signing fails
+	release := func() {}
+
+	// a session token signs for the key it was issued for
+	if m.Token != "" {
+		ss, err := s.sessions.get(m.Token)
+		if err != nil {
+			return signedStdTx, http.StatusUnauthorized, err
+		}
+
+		if m.Name == "" {
+			m.Name = ss.name
+		} else if m.Name != ss.name {
+			return signedStdTx, http.StatusUnauthorized, fmt.Errorf("session token was not issued for key %s", m.Name)
+		}
+	}
+
+	if entry != nil {
+		entry.Key = m.Name
+	}
+
+	if m.AccountNumber == "" || m.Sequence == "" {
+		info, err := s.keybase.Get(m.Name)
+		if err != nil {
+			return signedStdTx, http.StatusBadRequest, err
+		}
+
+		address := info.GetAddress()
+		if m.Sequence == "" {
+			accountNumber, sequence, err := s.sequences.Next(address, s.LoadAccount)
+			if err != nil {
+				return signedStdTx, accountLoadStatus(err), fmt.Errorf("failed to load account %s: %s", address, err.Error())
+			}
+
+			release = func() { s.sequences.Release(address, sequence) }
+			if m.AccountNumber == "" {
+				m.AccountNumber = strconv.FormatUint(accountNumber, 10)
+			}
+			m.Sequence = strconv.FormatUint(sequence, 10)
+		} else {
+			account, err := s.LoadAccount(address)
+			if err != nil {
+				return signedStdTx, accountLoadStatus(err), fmt.Errorf("failed to load account %s: %s", address, err.Error())
+			}
+
+			m.AccountNumber = strconv.FormatUint(account.GetAccountNumber(), 10)
+		}
+	}
+
+	stdSign, stdTx, err := m.StdSignMsg()
+	if err != nil {
+		release()
+		return signedStdTx, http.StatusBadRequest, err
+	}
+
+	signBytes := sdk.MustSortJSON(cdc.MustMarshalJSON(stdSign))
+	if entry != nil {
+		sum := sha256.Sum256(signBytes)
+		entry.SignBytesHash = hex.EncodeToString(sum[:])
+		entry.Msgs = msgTypes(stdSign.Msgs)
+	}
+
+	releaseSpend, err := s.policies.check(m.Name, stdSign)
+	if err != nil {
+		release()
+		s.instruments().signs.WithLabelValues(m.Name, signResultRejected).Inc()
+		return signedStdTx, http.StatusForbidden, err
+	}
+
+	var sigBytes []byte
+	var pubkey crypto.PubKey
+	if m.Token != "" {
+		sigBytes, pubkey, err = s.sessions.sign(s.keybase, m.Token, signBytes)
+	} else {
+		sigBytes, pubkey, err = s.keybase.Sign(m.Name, m.Passphrase, signBytes)
+	}
+	if err == errSessionNotFound {
+		release()
+		releaseSpend()
+		return signedStdTx, http.StatusUnauthorized, err
+	} else if err != nil {
+		release()
+		releaseSpend()
+		// names of keys that don't exist stay out of the labels
+		if !keyerror.IsErrKeyNotFound(err) {
+			s.instruments().signs.WithLabelValues(m.Name, signResultFailed).Inc()
+		}
+		return signedStdTx, http.StatusInternalServerError, err
+	}
+
+	s.instruments().signs.WithLabelValues(m.Name, signResultSigned).Inc()
+
 	pubkeys := append(stdTx.GetPubKeys(), pubkey)
 	sigbytes := append(stdTx.GetSignatures(), sigBytes)
 
@@ -116,15 +223,15 @@ func (s *Server) Sign(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	signedStdTx := auth.NewStdTx(stdTx.GetMsgs(), stdTx.Fee, sigs, stdTx.GetMemo())
-	out, err := cdc.MarshalJSON(signedStdTx)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write(newError(err).marshal())
-		return
+	return auth.NewStdTx(stdTx.GetMsgs(), stdTx.Fee, sigs, stdTx.GetMemo()), http.StatusOK, nil
+}
+
+// accountLoadStatus returns the http status to reply with when the account of the signer
+// couldn't be loaded
+func accountLoadStatus(err error) int {
+	if isUnreachable(err) {
+		return http.StatusBadGateway
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write(out)
-	return
+	return http.StatusBadRequest
 }
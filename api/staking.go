@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/terra-project/core/x/staking"
+)
+
+// DelegateBody contains the necessary data to make a delegate or undelegate transaction
+type DelegateBody struct {
+	Delegator sdk.AccAddress `json:"delegator"`
+	Validator sdk.ValAddress `json:"validator"`
+	Amount    string         `json:"amount"`
+	ChainID   string         `json:"chain_id"`
+	Memo      string         `json:"memo,omitempty"`
+	FeeOptions
+}
+
+// Marshal - nolint
+func (db DelegateBody) Marshal() []byte {
+	out, err := json.Marshal(db)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// RedelegateBody contains the necessary data to make a redelegate transaction
+type RedelegateBody struct {
+	Delegator    sdk.AccAddress `json:"delegator"`
+	ValidatorSrc sdk.ValAddress `json:"validator_src"`
+	ValidatorDst sdk.ValAddress `json:"validator_dst"`
+	Amount       string         `json:"amount"`
+	ChainID      string         `json:"chain_id"`
+	Memo         string         `json:"memo,omitempty"`
+	FeeOptions
+}
+
+// Marshal - nolint
+func (rb RedelegateBody) Marshal() []byte {
+	out, err := json.Marshal(rb)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// Delegate handles the /tx/staking/delegate route
+func (s *Server) Delegate(w http.ResponseWriter, r *http.Request) {
+	var db DelegateBody
+
+	err := readTxBody(r, &db)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	amount, err := sdk.ParseCoin(db.Amount)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("failed to parse amount %s into sdk.Coin", db.Amount)).marshal())
+		return
+	}
+
+	s.writeTx(
+		w,
+		[]sdk.Msg{staking.NewMsgDelegate(db.Delegator, db.Validator, amount)},
+		db.Memo,
+		db.FeeOptions,
+	)
+}
+
+// Undelegate handles the /tx/staking/undelegate route
+func (s *Server) Undelegate(w http.ResponseWriter, r *http.Request) {
+	var db DelegateBody
+
+	err := readTxBody(r, &db)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	amount, err := sdk.ParseCoin(db.Amount)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("failed to parse amount %s into sdk.Coin", db.Amount)).marshal())
+		return
+	}
+
+	s.writeTx(
+		w,
+		[]sdk.Msg{staking.NewMsgUndelegate(db.Delegator, db.Validator, amount)},
+		db.Memo,
+		db.FeeOptions,
+	)
+}
+
+// Redelegate handles the /tx/staking/redelegate route
+func (s *Server) Redelegate(w http.ResponseWriter, r *http.Request) {
+	var rb RedelegateBody
+
+	err := readTxBody(r, &rb)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	amount, err := sdk.ParseCoin(rb.Amount)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("failed to parse amount %s into sdk.Coin", rb.Amount)).marshal())
+		return
+	}
+
+	s.writeTx(
+		w,
+		[]sdk.Msg{staking.NewMsgBeginRedelegate(rb.Delegator, rb.ValidatorSrc, rb.ValidatorDst, amount)},
+		rb.Memo,
+		rb.FeeOptions,
+	)
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// SubmitBody is the body for a submit request, it carries either an unsigned
+// transaction in Tx or a send intent in Send
+type SubmitBody struct {
+	Tx            json.RawMessage `json:"tx,omitempty"`
+	Send          *BankSendBody   `json:"send,omitempty"`
+	Name          string          `json:"name"`
+	Passphrase    string          `json:"passphrase,omitempty"`
+	Token         string          `json:"token,omitempty"`
+	ChainID       string          `json:"chain_id"`
+	AccountNumber string          `json:"account_number,omitempty"`
+	Sequence      string          `json:"sequence,omitempty"`
+	Mode          string          `json:"mode,omitempty"`
+}
+
+// Marshal returns the json byte representation of the submit body
+func (sb SubmitBody) Marshal() []byte {
+	out, err := json.Marshal(sb)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// SubmitResponse is the response for a submit request
+type SubmitResponse struct {
+	TxHash        string         `json:"txhash"`
+	AccountNumber string         `json:"account_number"`
+	Sequence      string         `json:"sequence"`
+	Response      sdk.TxResponse `json:"response"`
+}
+
+// Submit handles the /tx/submit route
+func (s *Server) Submit(w http.ResponseWriter, r *http.Request) {
+	var sb SubmitBody
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	err = cdc.UnmarshalJSON(body, &sb)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	if (len(sb.Tx) == 0) == (sb.Send == nil) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("must include exactly one of tx and send with request")).marshal())
+		return
+	}
+
+	m := SignBody{
+		Tx:            sb.Tx,
+		Name:          sb.Name,
+		Passphrase:    sb.Passphrase,
+		Token:         sb.Token,
+		ChainID:       sb.ChainID,
+		AccountNumber: sb.AccountNumber,
+		Sequence:      sb.Sequence,
+	}
+
+	if sb.Send != nil {
+		stdTx, err := s.bankSendTx(*sb.Send)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(newError(err).marshal())
+			return
+		}
+
+		m.Tx = cdc.MustMarshalJSON(stdTx)
+		if m.ChainID == "" {
+			m.ChainID = sb.Send.ChainID
+		}
+	}
+
+	entry := auditEntry(r)
+	signedStdTx, status, err := s.signTx(&m, entry)
+	if err != nil {
+		w.WriteHeader(status)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	res, status, err := s.broadcastTx(signedStdTx, sb.Mode)
+	if err != nil {
+		w.WriteHeader(status)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	if entry != nil {
+		entry.TxHash = res.TxHash
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(cdc.MustMarshalJSON(SubmitResponse{
+		TxHash:        res.TxHash,
+		AccountNumber: m.AccountNumber,
+		Sequence:      m.Sequence,
+		Response:      res,
+	}))
+	return
+}
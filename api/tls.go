@@ -0,0 +1,119 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"sync"
+)
+
+// ClientCert grants its scopes to clients presenting a certificate of the client CA with
+// the subject, which is either the common name or the whole distinguished name
+type ClientCert struct {
+	Subject string   `json:"subject"`
+	Scopes  []string `json:"scopes"`
+}
+
+// tlsReloader holds the certificate of the server and the client CA pool, so both can be
+// swapped on SIGHUP while connections keep being served
+type tlsReloader struct {
+	mtx sync.RWMutex
+
+	certFile, keyFile, clientCAFile string
+
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// load reads the certificate, key and client CA, the current ones are kept on failure
+func (tr *tlsReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(tr.certFile, tr.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the tls certificate: %s", err.Error())
+	}
+
+	var clientCAs *x509.CertPool
+	if tr.clientCAFile != "" {
+		bz, err := ioutil.ReadFile(tr.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to load the client CA: %s", err.Error())
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(bz) {
+			return fmt.Errorf("no certificate found in the client CA %s", tr.clientCAFile)
+		}
+	}
+
+	tr.mtx.Lock()
+	defer tr.mtx.Unlock()
+
+	tr.cert = &cert
+	tr.clientCAs = clientCAs
+	return nil
+}
+
+// config returns the tls config of the certificates loaded last
+func (tr *tlsReloader) config(requireClientCert bool) *tls.Config {
+	tr.mtx.RLock()
+	defer tr.mtx.RUnlock()
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{*tr.cert},
+	}
+
+	if tr.clientCAs != nil {
+		config.ClientCAs = tr.clientCAs
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		if requireClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return config
+}
+
+// TLSConfig returns the tls config for serving HTTPS with TLSCert and TLSKey. With
+// TLSClientCA client certificates are verified, and required with TLSRequireClientCert.
+func (s *Server) TLSConfig() (*tls.Config, error) {
+	if s.TLSCert == "" || s.TLSKey == "" {
+		return nil, fmt.Errorf("tlscert and tlskey are required to serve HTTPS")
+	}
+
+	if s.TLSRequireClientCert && s.TLSClientCA == "" {
+		return nil, fmt.Errorf("tlsclientca is required to require client certificates")
+	}
+
+	if s.tls == nil {
+		tr := &tlsReloader{certFile: s.TLSCert, keyFile: s.TLSKey, clientCAFile: s.TLSClientCA}
+		if err := tr.load(); err != nil {
+			return nil, err
+		}
+		s.tls = tr
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// every handshake gets the certificates loaded last
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return s.tls.config(s.TLSRequireClientCert), nil
+		},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			s.tls.mtx.RLock()
+			defer s.tls.mtx.RUnlock()
+
+			return s.tls.cert, nil
+		},
+	}, nil
+}
+
+// ReloadTLS reads the certificate, key and client CA files again, new connections use them
+func (s *Server) ReloadTLS() error {
+	if s.tls == nil {
+		return fmt.Errorf("the server doesn't serve HTTPS")
+	}
+
+	return s.tls.load()
+}
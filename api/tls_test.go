@@ -0,0 +1,197 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testCert creates a certificate for cn signed by parent, or a self signed CA without a parent
+func testCert(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, tls.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"keyserver"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key, tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// writePEM writes the certificate and key as PEM files in dir
+func writePEM(t *testing.T, dir, name string, cert *x509.Certificate, key *ecdsa.PrivateKey) (certFile, keyFile string) {
+	certFile = filepath.Join(dir, name+".crt")
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0600))
+
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	keyFile = filepath.Join(dir, name+".key")
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+
+	return certFile, keyFile
+}
+
+func TestTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca, caKey, _ := testCert(t, "ca", nil, nil)
+	caFile, _ := writePEM(t, dir, "ca", ca, caKey)
+	serverCert, serverKey, _ := testCert(t, "server", ca, caKey)
+	certFile, keyFile := writePEM(t, dir, "server", serverCert, serverKey)
+	_, _, reader := testCert(t, "reader", ca, caKey)
+	_, _, unknown := testCert(t, "unknown", ca, caKey)
+
+	// a client certificate of another CA isn't trusted
+	otherCA, otherCAKey, _ := testCert(t, "ca", nil, nil)
+	_, _, untrusted := testCert(t, "reader", otherCA, otherCAKey)
+
+	s := &Server{
+		KeyringBackend: KeyringBackendMemory,
+		TLSCert:        certFile,
+		TLSKey:         keyFile,
+		TLSClientCA:    caFile,
+		ClientCerts:    []ClientCert{{Subject: "reader", Scopes: []string{ScopeKeysRead}}},
+		Tokens:         []APIToken{{Name: "admin", Token: "admintoken", Scopes: []string{ScopeAll}}},
+	}
+
+	tlsConfig, err := s.TLSConfig()
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(s.Router())
+	server.TLS = tlsConfig
+	server.StartTLS()
+	defer server.Close()
+	defer s.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	client := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs}}}
+	}
+	get := func(c *http.Client, route string, token string) (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+route, nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := c.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+	requireStatus := func(c *http.Client, route, token string, expStatus int) {
+		resp, err := get(c, route, token)
+		require.NoError(t, err)
+		require.Equal(t, expStatus, resp.StatusCode, route)
+	}
+
+	// test the subject of a verified client certificate carries its scopes
+	requireStatus(client(reader), "/keys", "", 200)
+	requireStatus(client(reader), "/treasury", "", 403)
+	requireStatus(client(unknown), "/keys", "", 401)
+
+	// test bearer tokens work with or without a client certificate
+	requireStatus(client(), "/keys", "admintoken", 200)
+	requireStatus(client(unknown), "/keys", "admintoken", 200)
+
+	_, err = get(client(untrusted), "/keys", "")
+	require.Error(t, err)
+
+	// test certificates are reloaded for new connections
+	newCert, newKey, _ := testCert(t, "server", ca, caKey)
+	writePEM(t, dir, "server", newCert, newKey)
+	require.NoError(t, s.ReloadTLS())
+	resp, err := get(client(reader), "/keys", "")
+	require.NoError(t, err)
+	require.Equal(t, newCert.Raw, resp.TLS.PeerCertificates[0].Raw)
+
+	// test a broken certificate keeps the current one
+	require.NoError(t, ioutil.WriteFile(certFile, []byte("broken"), 0600))
+	require.Error(t, s.ReloadTLS())
+	resp, err = get(client(reader), "/keys", "")
+	require.NoError(t, err)
+	require.Equal(t, newCert.Raw, resp.TLS.PeerCertificates[0].Raw)
+}
+
+func TestTLSRequireClientCert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca, caKey, _ := testCert(t, "ca", nil, nil)
+	caFile, _ := writePEM(t, dir, "ca", ca, caKey)
+	serverCert, serverKey, _ := testCert(t, "server", ca, caKey)
+	certFile, keyFile := writePEM(t, dir, "server", serverCert, serverKey)
+	_, _, reader := testCert(t, "reader", ca, caKey)
+
+	s := &Server{KeyringBackend: KeyringBackendMemory, TLSCert: certFile, TLSKey: keyFile, TLSClientCA: caFile, TLSRequireClientCert: true}
+	tlsConfig, err := s.TLSConfig()
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(s.Router())
+	server.TLS = tlsConfig
+	server.StartTLS()
+	defer server.Close()
+	defer s.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	// test connections without a client certificate are refused
+	_, err = (&http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}).Get(server.URL + "/keys")
+	require.Error(t, err)
+
+	resp, err := (&http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{reader}}}}).Get(server.URL + "/keys")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, 200, resp.StatusCode)
+}
+
+func TestTLSConfigRequiresFiles(t *testing.T) {
+	_, err := (&Server{}).TLSConfig()
+	require.Error(t, err)
+
+	_, err = (&Server{TLSCert: "cert.pem", TLSKey: "key.pem", TLSRequireClientCert: true}).TLSConfig()
+	require.Error(t, err)
+
+	_, err = (&Server{TLSCert: "missing.pem", TLSKey: "missing.pem"}).TLSConfig()
+	require.Error(t, err)
+
+	require.Error(t, (&Server{}).ReloadTLS())
+}
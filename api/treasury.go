@@ -0,0 +1,195 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	core "github.com/terra-project/core/types"
+)
+
+const defaultTreasuryTTL = 10 * time.Minute
+
+// TaxCap is the tax cap of a denom
+type TaxCap struct {
+	Denom  string  `json:"denom"`
+	TaxCap sdk.Int `json:"tax_cap"`
+}
+
+// TreasuryResponse is the response for the /treasury route
+type TreasuryResponse struct {
+	TaxRate   sdk.Dec   `json:"tax_rate"`
+	TaxCaps   []TaxCap  `json:"tax_caps"`
+	Height    int64     `json:"height"`
+	Epoch     int64     `json:"epoch"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// treasuryCache caches the tax rate and tax caps, which only change at treasury epoch
+// boundaries. The values are dropped once the ttl elapsed or a query saw a new epoch.
+type treasuryCache struct {
+	// loadMtx serializes loads, so a burst of requests queries the node once
+	loadMtx sync.Mutex
+
+	mtx          sync.Mutex
+	ttl          time.Duration
+	latestHeight int64
+	epoch        int64
+	updatedAt    time.Time
+	taxRate      *sdk.Dec
+	taxCaps      map[string]sdk.Int
+}
+
+func newTreasuryCache(ttl time.Duration) *treasuryCache {
+	if ttl == 0 {
+		ttl = defaultTreasuryTTL
+	}
+
+	return &treasuryCache{ttl: ttl, taxCaps: make(map[string]sdk.Int)}
+}
+
+// epochOf returns the treasury epoch of the state at height, the policy of the next
+// epoch is set in the last block of the previous one
+func epochOf(height int64) int64 {
+	return (height + 1) / core.BlocksPerWeek
+}
+
+// observe records a height the node answered a query at
+func (c *treasuryCache) observe(height int64) {
+	if c == nil {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if height > c.latestHeight {
+		c.latestHeight = height
+	}
+}
+
+// fresh must be called with mtx held
+func (c *treasuryCache) fresh() bool {
+	return !c.updatedAt.IsZero() &&
+		time.Since(c.updatedAt) < c.ttl &&
+		epochOf(c.latestHeight) == c.epoch
+}
+
+// store runs set with mtx held, after dropping the values when they are stale
+func (c *treasuryCache) store(set func()) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if !c.fresh() {
+		c.taxRate = nil
+		c.taxCaps = make(map[string]sdk.Int)
+		c.epoch = epochOf(c.latestHeight)
+		c.updatedAt = time.Now()
+	}
+
+	set()
+}
+
+func (c *treasuryCache) cachedTaxRate() (sdk.Dec, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if !c.fresh() || c.taxRate == nil {
+		return sdk.Dec{}, false
+	}
+
+	return *c.taxRate, true
+}
+
+func (c *treasuryCache) cachedTaxCap(denom string) (sdk.Int, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if !c.fresh() {
+		return sdk.Int{}, false
+	}
+
+	taxCap, ok := c.taxCaps[denom]
+	return taxCap, ok
+}
+
+// TaxRate returns the tax rate from the treasury cache, loading it from the node when stale
+func (s *Server) TaxRate() (sdk.Dec, error) {
+	c := s.treasury
+	if c == nil {
+		return s.LoadTaxRate()
+	}
+
+	c.loadMtx.Lock()
+	defer c.loadMtx.Unlock()
+
+	if taxRate, ok := c.cachedTaxRate(); ok {
+		return taxRate, nil
+	}
+
+	taxRate, err := s.LoadTaxRate()
+	if err != nil {
+		return taxRate, err
+	}
+
+	c.store(func() { c.taxRate = &taxRate })
+	return taxRate, nil
+}
+
+// TaxCap returns the tax cap of denom from the treasury cache, loading it from the node when stale
+func (s *Server) TaxCap(denom string) (sdk.Int, error) {
+	c := s.treasury
+	if c == nil {
+		return s.LoadTaxCap(denom)
+	}
+
+	c.loadMtx.Lock()
+	defer c.loadMtx.Unlock()
+
+	if taxCap, ok := c.cachedTaxCap(denom); ok {
+		return taxCap, nil
+	}
+
+	taxCap, err := s.LoadTaxCap(denom)
+	if err != nil {
+		return taxCap, err
+	}
+
+	c.store(func() { c.taxCaps[denom] = taxCap })
+	return taxCap, nil
+}
+
+// Treasury handles the /treasury route
+func (s *Server) Treasury(w http.ResponseWriter, r *http.Request) {
+	taxRate, err := s.TaxRate()
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	c := s.treasury
+	c.mtx.Lock()
+	res := TreasuryResponse{
+		TaxRate:   taxRate,
+		TaxCaps:   []TaxCap{},
+		Height:    c.latestHeight,
+		Epoch:     c.epoch,
+		UpdatedAt: c.updatedAt,
+		ExpiresAt: c.updatedAt.Add(c.ttl),
+	}
+	for denom, taxCap := range c.taxCaps {
+		res.TaxCaps = append(res.TaxCaps, TaxCap{Denom: denom, TaxCap: taxCap})
+	}
+	c.mtx.Unlock()
+
+	sort.Slice(res.TaxCaps, func(i, j int) bool { return res.TaxCaps[i].Denom < res.TaxCaps[j].Denom })
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(cdc.MustMarshalJSON(res))
+	return
+}
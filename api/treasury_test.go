@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/stretchr/testify/require"
+
+	core "github.com/terra-project/core/types"
+)
+
+func TestTreasuryCache(t *testing.T) {
+	var queries, height int64
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Params struct {
+				Path string `json:"path"`
+			} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		atomic.AddInt64(&queries, 1)
+
+		value := `"1000000"`
+		if req.Params.Path == "custom/treasury/taxRate" {
+			value = `"0.001000000000000000"`
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"response":{"value":"%s","height":"%d"}}}`,
+			req.ID, base64.StdEncoding.EncodeToString([]byte(value)), atomic.LoadInt64(&height))
+	}))
+	defer node.Close()
+
+	s := &Server{Node: node.URL, KeyringBackend: KeyringBackendMemory}
+	s.Router()
+
+	sender, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+	msgs := []sdk.Msg{bank.NewMsgSend(sender, sender, sdk.NewCoins(sdk.NewInt64Coin("ukrw", 1000), sdk.NewInt64Coin("uusd", 1000)))}
+
+	taxes, err := s.ComputeTax(msgs)
+	require.NoError(t, err)
+	require.Equal(t, "1ukrw,1uusd", taxes.String())
+	require.Equal(t, int64(3), queries)
+
+	// test a burst of requests within the epoch is served from the cache
+	for i := 0; i < 5; i++ {
+		_, err = s.ComputeTax(msgs)
+		require.NoError(t, err)
+	}
+	require.Equal(t, int64(3), queries)
+
+	// test a query seeing the next epoch drops the cached values
+	atomic.StoreInt64(&height, core.BlocksPerWeek-1)
+	_, err = s.queryABCI("/app/simulate", nil)
+	require.NoError(t, err)
+	_, err = s.ComputeTax(msgs)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), queries)
+	require.Equal(t, int64(1), s.treasury.epoch)
+
+	// test the ttl drops the cached values
+	s.treasury.updatedAt = s.treasury.updatedAt.Add(-s.treasury.ttl)
+	_, err = s.ComputeTax(msgs)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), queries)
+}
+
+func TestGetTreasury(t *testing.T) {
+	server := setup(t)
+	defer server.Close()
+
+	// test no node
+	getRoute(t, fmt.Sprintf("%s/treasury", server.URL), 502)
+}
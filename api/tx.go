@@ -0,0 +1,141 @@
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/gorilla/mux"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	httprpcclient "github.com/tendermint/tendermint/rpc/client/http"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+const (
+	// TxStatusCommitted is the status of a transaction included in a block
+	TxStatusCommitted = "committed"
+	// TxStatusPending is the status of a transaction waiting in the node mempool
+	TxStatusPending = "pending"
+	// TxStatusUnknown is the status of a transaction that isn't in a block and wasn't found in
+	// the part of the mempool the node returns, it may still be pending
+	TxStatusUnknown = "unknown"
+
+	// maxUnconfirmedTxs is the most mempool transactions the node returns, it has no paging
+	maxUnconfirmedTxs = 100
+)
+
+// TxStatusResponse is the response for a transaction lookup
+type TxStatusResponse struct {
+	Status    string              `json:"status"`
+	TxHash    string              `json:"txhash"`
+	Height    int64               `json:"height,omitempty"`
+	Code      uint32              `json:"code"`
+	Codespace string              `json:"codespace,omitempty"`
+	RawLog    string              `json:"raw_log,omitempty"`
+	Logs      sdk.ABCIMessageLogs `json:"logs,omitempty"`
+	GasWanted int64               `json:"gas_wanted,omitempty"`
+	GasUsed   int64               `json:"gas_used,omitempty"`
+	Fee       auth.StdFee         `json:"fee"`
+	Msgs      []sdk.Msg           `json:"msgs"`
+	Memo      string              `json:"memo,omitempty"`
+}
+
+// GetTx handles the GET /tx/{hash} route
+func (s *Server) GetTx(w http.ResponseWriter, r *http.Request) {
+	hash, err := hex.DecodeString(mux.Vars(r)["hash"])
+	if err != nil || len(hash) != tmhash.Size {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("invalid tx hash %s", mux.Vars(r)["hash"])).marshal())
+		return
+	}
+
+	var res *ctypes.ResultTx
+	err = s.nodes.do(func(client *httprpcclient.HTTP) (err error) {
+		res, err = client.Tx(hash, false)
+		return err
+	})
+	if err == nil {
+		var stdTx auth.StdTx
+		if err := cdc.UnmarshalBinaryLengthPrefixed(res.Tx, &stdTx); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write(newError(err).marshal())
+			return
+		}
+
+		logs, _ := sdk.ParseABCILogs(res.TxResult.Log)
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(cdc.MustMarshalJSON(TxStatusResponse{
+			Status:    TxStatusCommitted,
+			TxHash:    res.Hash.String(),
+			Height:    res.Height,
+			Code:      res.TxResult.Code,
+			Codespace: res.TxResult.Codespace,
+			RawLog:    res.TxResult.Log,
+			Logs:      logs,
+			GasWanted: res.TxResult.GasWanted,
+			GasUsed:   res.TxResult.GasUsed,
+			Fee:       stdTx.Fee,
+			Msgs:      stdTx.Msgs,
+			Memo:      stdTx.Memo,
+		}))
+		return
+	} else if !strings.Contains(err.Error(), "not found") {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	// not in a block yet, look for it in the mempool
+	var unconfirmed *ctypes.ResultUnconfirmedTxs
+	err = s.nodes.do(func(client *httprpcclient.HTTP) (err error) {
+		unconfirmed, err = client.UnconfirmedTxs(maxUnconfirmedTxs)
+		return err
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	for _, tx := range unconfirmed.Txs {
+		if !bytes.Equal(tx.Hash(), hash) {
+			continue
+		}
+
+		var stdTx auth.StdTx
+		if err := cdc.UnmarshalBinaryLengthPrefixed(tx, &stdTx); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write(newError(err).marshal())
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(cdc.MustMarshalJSON(TxStatusResponse{
+			Status: TxStatusPending,
+			TxHash: fmt.Sprintf("%X", hash),
+			Fee:    stdTx.Fee,
+			Msgs:   stdTx.Msgs,
+			Memo:   stdTx.Memo,
+		}))
+		return
+	}
+
+	// the rest of the mempool can't be searched
+	if unconfirmed.Total > len(unconfirmed.Txs) {
+		w.WriteHeader(http.StatusOK)
+		w.Write(cdc.MustMarshalJSON(TxStatusResponse{
+			Status: TxStatusUnknown,
+			TxHash: fmt.Sprintf("%X", hash),
+		}))
+		return
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+	w.Write(newError(fmt.Errorf("tx %X not found", hash)).marshal())
+	return
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/stretchr/testify/require"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+func TestGetTx(t *testing.T) {
+	sender, err := sdk.AccAddressFromBech32(sAcc)
+	require.NoError(t, err)
+	coins := sdk.NewCoins(sdk.NewInt64Coin("uluna", 1000))
+	stdTx := auth.NewStdTx([]sdk.Msg{bank.NewMsgSend(sender, sender, coins)}, auth.NewStdFee(200000, coins), nil, "memo")
+
+	txBytes := tmtypes.Tx(cdc.MustMarshalBinaryLengthPrefixed(stdTx))
+	txBase64 := base64.StdEncoding.EncodeToString(txBytes)
+	other := base64.StdEncoding.EncodeToString([]byte("other"))
+	hash := fmt.Sprintf("%X", txBytes.Hash())
+	notFound := map[string]*rpctypes.RPCError{
+		"tx": {Code: -32603, Message: "Internal error", Data: fmt.Sprintf("tx (%s) not found", hash)},
+	}
+
+	getTx := func(results map[string]string, rpcErrs map[string]*rpctypes.RPCError, expStatus int) (res TxStatusResponse) {
+		node := fakeNodeMethods(results, rpcErrs)
+		defer node.Close()
+
+		s := &Server{KeyringBackend: KeyringBackendMemory, Node: node.URL}
+		server := httptest.NewServer(s.Router())
+		defer server.Close()
+		defer s.Close()
+
+		out := getRoute(t, fmt.Sprintf("%s/tx/%s", server.URL, hash), expStatus)
+		if expStatus == 200 {
+			require.NoError(t, cdc.UnmarshalJSON(out, &res))
+		}
+		return res
+	}
+
+	// test a transaction in a block
+	res := getTx(map[string]string{
+		"tx": fmt.Sprintf(`{"hash":"%s","height":"12","index":0,"tx_result":{"code":0,"log":"[]","gas_wanted":"200000","gas_used":"51234"},"tx":"%s"}`, hash, txBase64),
+	}, nil, 200)
+	require.Equal(t, TxStatusCommitted, res.Status)
+	require.Equal(t, hash, res.TxHash)
+	require.Equal(t, int64(12), res.Height)
+	require.Equal(t, int64(51234), res.GasUsed)
+	require.Equal(t, "memo", res.Memo)
+	require.Len(t, res.Msgs, 1)
+
+	// test a transaction in the mempool
+	res = getTx(map[string]string{
+		"unconfirmed_txs": fmt.Sprintf(`{"n_txs":"2","total":"2","total_bytes":"0","txs":["%s","%s"]}`, other, txBase64),
+	}, notFound, 200)
+	require.Equal(t, TxStatusPending, res.Status)
+	require.Equal(t, hash, res.TxHash)
+	require.Equal(t, stdTx.Fee, res.Fee)
+
+	// test a transaction the node doesn't know
+	getTx(map[string]string{
+		"unconfirmed_txs": fmt.Sprintf(`{"n_txs":"1","total":"1","total_bytes":"0","txs":["%s"]}`, other),
+	}, notFound, 404)
+
+	// test a transaction past the part of the mempool the node returns may still be pending
+	res = getTx(map[string]string{
+		"unconfirmed_txs": fmt.Sprintf(`{"n_txs":"1","total":"250","total_bytes":"0","txs":["%s"]}`, other),
+	}, notFound, 200)
+	require.Equal(t, TxStatusUnknown, res.Status)
+	require.Equal(t, hash, res.TxHash)
+
+	// test the node failing
+	getTx(nil, map[string]*rpctypes.RPCError{"tx": {Code: -32603, Message: "Internal error", Data: "database is down"}}, 502)
+
+	// test invalid hash
+	server := setup(t)
+	defer server.Close()
+	getRoute(t, fmt.Sprintf("%s/tx/foo", server.URL), 400)
+	getRoute(t, fmt.Sprintf("%s/tx/ABCD", server.URL), 400)
+}
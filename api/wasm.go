@@ -0,0 +1,223 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/terra-project/core/x/wasm"
+)
+
+// WasmStoreBody contains the necessary data to make a store code transaction,
+// WASMByteCode is base64 encoded and gzip compressed unless it already is
+type WasmStoreBody struct {
+	Sender       sdk.AccAddress `json:"sender"`
+	WASMByteCode []byte         `json:"wasm_byte_code"`
+	ChainID      string         `json:"chain_id"`
+	Memo         string         `json:"memo,omitempty"`
+	FeeOptions
+}
+
+// Marshal - nolint
+func (sb WasmStoreBody) Marshal() []byte {
+	out, err := json.Marshal(sb)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// WasmInstantiateBody contains the necessary data to make an instantiate contract transaction,
+// InitMsg is either a JSON value or a base64 encoded JSON string
+type WasmInstantiateBody struct {
+	Owner      sdk.AccAddress  `json:"owner"`
+	CodeID     string          `json:"code_id"`
+	InitMsg    json.RawMessage `json:"init_msg"`
+	InitCoins  string          `json:"init_coins,omitempty"`
+	Migratable bool            `json:"migratable,omitempty"`
+	ChainID    string          `json:"chain_id"`
+	Memo       string          `json:"memo,omitempty"`
+	FeeOptions
+}
+
+// Marshal - nolint
+func (ib WasmInstantiateBody) Marshal() []byte {
+	out, err := json.Marshal(ib)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// WasmExecuteBody contains the necessary data to make an execute contract transaction,
+// ExecuteMsg is either a JSON value or a base64 encoded JSON string
+type WasmExecuteBody struct {
+	Sender     sdk.AccAddress  `json:"sender"`
+	Contract   sdk.AccAddress  `json:"contract"`
+	ExecuteMsg json.RawMessage `json:"execute_msg"`
+	Coins      string          `json:"coins,omitempty"`
+	ChainID    string          `json:"chain_id"`
+	Memo       string          `json:"memo,omitempty"`
+	FeeOptions
+}
+
+// Marshal - nolint
+func (eb WasmExecuteBody) Marshal() []byte {
+	out, err := json.Marshal(eb)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// decodeContractMsg returns the JSON bytes of a contract message given either
+// as a JSON value or as a base64 encoded JSON string
+func decodeContractMsg(raw json.RawMessage) ([]byte, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("contract message is empty")
+	}
+
+	msg := []byte(raw)
+	if raw[0] == '"' {
+		var encoded string
+		if err := json.Unmarshal(raw, &encoded); err != nil {
+			return nil, err
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode base64 contract message: %s", err.Error())
+		}
+		msg = decoded
+	}
+
+	if !json.Valid(msg) {
+		return nil, fmt.Errorf("contract message is not valid JSON")
+	}
+
+	return msg, nil
+}
+
+// gzipByteCode compresses wasm byte code, leaving already compressed byte code as is
+func gzipByteCode(byteCode []byte) ([]byte, error) {
+	if len(byteCode) >= 2 && byteCode[0] == 0x1f && byteCode[1] == 0x8b {
+		return byteCode, nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(byteCode); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// WasmStore handles the /tx/wasm/store route
+func (s *Server) WasmStore(w http.ResponseWriter, r *http.Request) {
+	var sb WasmStoreBody
+
+	err := readTxBody(r, &sb)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	byteCode, err := gzipByteCode(sb.WASMByteCode)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	s.writeTx(
+		w,
+		[]sdk.Msg{wasm.NewMsgStoreCode(sb.Sender, byteCode)},
+		sb.Memo,
+		sb.FeeOptions,
+	)
+}
+
+// WasmInstantiate handles the /tx/wasm/instantiate route
+func (s *Server) WasmInstantiate(w http.ResponseWriter, r *http.Request) {
+	var ib WasmInstantiateBody
+
+	err := readTxBody(r, &ib)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	codeID, err := strconv.ParseUint(ib.CodeID, 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("failed to parse code id %s into uint64", ib.CodeID)).marshal())
+		return
+	}
+
+	initMsg, err := decodeContractMsg(ib.InitMsg)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	initCoins, err := sdk.ParseCoins(ib.InitCoins)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("failed to parse init coins %s into sdk.Coins", ib.InitCoins)).marshal())
+		return
+	}
+
+	s.writeTx(
+		w,
+		[]sdk.Msg{wasm.NewMsgInstantiateContract(ib.Owner, codeID, initMsg, initCoins, ib.Migratable)},
+		ib.Memo,
+		ib.FeeOptions,
+	)
+}
+
+// WasmExecute handles the /tx/wasm/execute route
+func (s *Server) WasmExecute(w http.ResponseWriter, r *http.Request) {
+	var eb WasmExecuteBody
+
+	err := readTxBody(r, &eb)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	executeMsg, err := decodeContractMsg(eb.ExecuteMsg)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(err).marshal())
+		return
+	}
+
+	coins, err := sdk.ParseCoins(eb.Coins)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(newError(fmt.Errorf("failed to parse coins %s into sdk.Coins", eb.Coins)).marshal())
+		return
+	}
+
+	s.writeTx(
+		w,
+		[]sdk.Msg{wasm.NewMsgExecuteContract(eb.Sender, eb.Contract, executeMsg, coins)},
+		eb.Memo,
+		eb.FeeOptions,
+	)
+}
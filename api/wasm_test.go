@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeContractMsg(t *testing.T) {
+	msg := []byte(`{"transfer":{"amount":"100"}}`)
+
+	// test raw JSON
+	decoded, err := decodeContractMsg(json.RawMessage(msg))
+	require.NoError(t, err)
+	require.Equal(t, msg, decoded)
+
+	// test base64 encoded JSON
+	encoded, err := json.Marshal(base64.StdEncoding.EncodeToString(msg))
+	require.NoError(t, err)
+	decoded, err = decodeContractMsg(json.RawMessage(encoded))
+	require.NoError(t, err)
+	require.Equal(t, msg, decoded)
+
+	// test invalid msgs
+	_, err = decodeContractMsg(nil)
+	require.Error(t, err)
+	_, err = decodeContractMsg(json.RawMessage(`"bm90IGpzb24="`))
+	require.Error(t, err)
+	_, err = decodeContractMsg(json.RawMessage(`"%%%"`))
+	require.Error(t, err)
+}
+
+func TestGzipByteCode(t *testing.T) {
+	byteCode := []byte("\x00asm\x01\x00\x00\x00")
+
+	zipped, err := gzipByteCode(byteCode)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x1f, 0x8b}, zipped[:2])
+
+	// test compressed byte code is left as is
+	rezipped, err := gzipByteCode(zipped)
+	require.NoError(t, err)
+	require.Equal(t, zipped, rezipped)
+}
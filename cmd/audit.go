@@ -0,0 +1,64 @@
+// Copyright © 2018 Jack Zampolin <jack@blockstack.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/terra-project/keyserver/api"
+)
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspects the audit log",
+}
+
+// auditVerify checks the hash chain of the audit log
+var auditVerify = &cobra.Command{
+	Use:   "verify [file]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "Verify the hash chain of the audit log, the one of the config by default",
+	Run: func(cmd *cobra.Command, args []string) {
+		path := server.AuditLog
+		if len(args) == 1 {
+			path = args[0]
+		}
+		if path == "" {
+			log.Fatalf("no audit log given and none configured")
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("failed to open the audit log: %s", err)
+		}
+		defer file.Close()
+
+		entries, last, err := api.VerifyAuditLog(file)
+		if err != nil {
+			log.Fatalf("audit log %s doesn't verify after %d entries: %s", path, entries, err)
+		}
+
+		fmt.Printf("audit log %s verified: %d entries, last hash %s\n", path, entries, last)
+	},
+}
+
+func init() {
+	auditCmd.AddCommand(auditVerify)
+	rootCmd.AddCommand(auditCmd)
+}
@@ -17,6 +17,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
@@ -39,6 +40,20 @@ var configCmd = &cobra.Command{
 			Port:   3000,
 			KeyDir: fmt.Sprintf("%s/.keyserver", home),
 			Node:   "http://localhost:26657",
+
+			KeyringBackend: api.KeyringBackendLegacy,
+
+			RPCTimeout:          15 * time.Second,
+			HealthCheckInterval: 10 * time.Second,
+			TreasuryTTL:         10 * time.Minute,
+			SessionMaxTTL:       time.Hour,
+			BroadcastTimeout:    time.Minute,
+			AuditLog:            fmt.Sprintf("%s/.keyserver/audit.log", home),
+
+			ReadTimeout:     30 * time.Second,
+			WriteTimeout:    2 * time.Minute,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: time.Minute,
 		}
 
 		if _, err := os.Stat(s.KeyDir); os.IsNotExist(err) {
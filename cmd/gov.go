@@ -0,0 +1,106 @@
+// Copyright © 2018 Jack Zampolin <jack@blockstack.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/terra-project/keyserver/api"
+)
+
+var proposalDeposit string
+
+var govCmd = &cobra.Command{
+	Use:   "gov",
+	Short: "governance transactions",
+}
+
+var govVoteCmd = &cobra.Command{
+	Use:   "vote [voter] [proposal-id] [option] [chain-id]",
+	Short: "generate a vote transaction, option is one of yes, no, no_with_veto and abstain",
+	Args:  cobra.ExactArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		voter, err := sdk.AccAddressFromBech32(args[0])
+		if err != nil {
+			log.Fatalf("invalid voter address %s", args[0])
+		}
+
+		vb := api.GovVoteBody{
+			Voter:      voter,
+			ProposalID: args[1],
+			Option:     args[2],
+			ChainID:    args[3],
+			Memo:       txMemo,
+			FeeOptions: txFeeOptions(),
+		}
+		postTx("/tx/gov/vote", vb.Marshal())
+	},
+}
+
+var govDepositCmd = &cobra.Command{
+	Use:   "deposit [depositor] [proposal-id] [amount] [chain-id]",
+	Short: "generate a deposit transaction",
+	Args:  cobra.ExactArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		depositor, err := sdk.AccAddressFromBech32(args[0])
+		if err != nil {
+			log.Fatalf("invalid depositor address %s", args[0])
+		}
+
+		db := api.GovDepositBody{
+			Depositor:  depositor,
+			ProposalID: args[1],
+			Amount:     args[2],
+			ChainID:    args[3],
+			Memo:       txMemo,
+			FeeOptions: txFeeOptions(),
+		}
+		postTx("/tx/gov/deposit", db.Marshal())
+	},
+}
+
+var govProposalCmd = &cobra.Command{
+	Use:   "proposal [proposer] [title] [description] [chain-id]",
+	Short: "generate a text proposal transaction",
+	Args:  cobra.ExactArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		proposer, err := sdk.AccAddressFromBech32(args[0])
+		if err != nil {
+			log.Fatalf("invalid proposer address %s", args[0])
+		}
+
+		pb := api.GovProposalBody{
+			Proposer:       proposer,
+			Title:          args[1],
+			Description:    args[2],
+			InitialDeposit: proposalDeposit,
+			ChainID:        args[3],
+			Memo:           txMemo,
+			FeeOptions:     txFeeOptions(),
+		}
+		postTx("/tx/gov/proposal", pb.Marshal())
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{govVoteCmd, govDepositCmd, govProposalCmd} {
+		addFeeFlags(cmd)
+		govCmd.AddCommand(cmd)
+	}
+	govProposalCmd.Flags().StringVar(&proposalDeposit, "deposit", "", "initial deposit of the proposal, e.g. 512000000uluna")
+	txCmd.AddCommand(govCmd)
+}
@@ -176,11 +176,44 @@ var keyDelete = &cobra.Command{
 	},
 }
 
+var (
+	unlockTTL     string
+	unlockMaxUses int
+	lockToken     string
+)
+
+// /keys/{name}/unlock POST
+var keyUnlock = &cobra.Command{
+	Use:   "unlock [name] [password]",
+	Args:  cobra.ExactArgs(2),
+	Short: "Unlock a key, the returned token signs in place of the password until it expires",
+	Run: func(cmd *cobra.Command, args []string) {
+		ub := api.UnlockBody{Passphrase: args[1], TTL: unlockTTL, MaxUses: unlockMaxUses}
+		postTx(fmt.Sprintf("/keys/%s/unlock", args[0]), ub.Marshal())
+	},
+}
+
+// /keys/{name}/lock POST
+var keyLock = &cobra.Command{
+	Use:   "lock [name]",
+	Args:  cobra.ExactArgs(1),
+	Short: "Lock a key, ending the session of --token or all sessions of the key",
+	Run: func(cmd *cobra.Command, args []string) {
+		lb := api.LockBody{Token: lockToken}
+		postTx(fmt.Sprintf("/keys/%s/lock", args[0]), lb.Marshal())
+	},
+}
+
 func init() {
 	keysCmd.AddCommand(keysGet)
 	keysCmd.AddCommand(keysPost)
 	keysCmd.AddCommand(keyGet)
 	keysCmd.AddCommand(keyPut)
 	keysCmd.AddCommand(keyDelete)
+	keysCmd.AddCommand(keyUnlock)
+	keyUnlock.Flags().StringVar(&unlockTTL, "ttl", "", "how long the key stays unlocked, e.g. 10m")
+	keyUnlock.Flags().IntVar(&unlockMaxUses, "max-uses", 0, "how many signatures the token makes, unlimited when 0")
+	keysCmd.AddCommand(keyLock)
+	keyLock.Flags().StringVar(&lockToken, "token", "", "session token to end")
 	rootCmd.AddCommand(keysCmd)
 }
@@ -0,0 +1,68 @@
+// Copyright © 2018 Jack Zampolin <jack@blockstack.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/terra-project/keyserver/api"
+)
+
+var swapReceiver string
+
+var marketCmd = &cobra.Command{
+	Use:   "market",
+	Short: "market transactions",
+}
+
+var swapCmd = &cobra.Command{
+	Use:   "swap [trader] [offer-coin] [ask-denom] [chain-id]",
+	Short: "generate a swap transaction, sending the swapped coins to --receiver when given",
+	Args:  cobra.ExactArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		trader, err := sdk.AccAddressFromBech32(args[0])
+		if err != nil {
+			log.Fatalf("invalid trader address %s", args[0])
+		}
+
+		var receiver sdk.AccAddress
+		if swapReceiver != "" {
+			receiver, err = sdk.AccAddressFromBech32(swapReceiver)
+			if err != nil {
+				log.Fatalf("invalid receiver address %s", swapReceiver)
+			}
+		}
+
+		sb := api.SwapBody{
+			Trader:     trader,
+			Receiver:   receiver,
+			OfferCoin:  args[1],
+			AskDenom:   args[2],
+			ChainID:    args[3],
+			Memo:       txMemo,
+			FeeOptions: txFeeOptions(),
+		}
+		postTx("/tx/market/swap", sb.Marshal())
+	},
+}
+
+func init() {
+	addFeeFlags(swapCmd)
+	swapCmd.Flags().StringVar(&swapReceiver, "receiver", "", "address receiving the swapped coins, defaults to the trader")
+	marketCmd.AddCommand(swapCmd)
+	txCmd.AddCommand(marketCmd)
+}
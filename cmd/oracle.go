@@ -0,0 +1,95 @@
+// Copyright © 2018 Jack Zampolin <jack@blockstack.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/terra-project/keyserver/api"
+)
+
+var voteExchangeRates string
+
+var oracleCmd = &cobra.Command{
+	Use:   "oracle",
+	Short: "oracle transactions, the keyserver keeps the prevote salts",
+}
+
+var prevoteCmd = &cobra.Command{
+	Use:   "prevote [feeder] [validator] [exchange-rates] [chain-id]",
+	Short: "generate an aggregate prevote transaction for exchange rates like 8900.5ukrw,7.25uusd",
+	Args:  cobra.ExactArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		ob := oracleBody(args[0], args[1], args[3])
+		ob.ExchangeRates = parseExchangeRates(args[2])
+		postTx("/tx/oracle/prevote", ob.Marshal())
+	},
+}
+
+var voteCmd = &cobra.Command{
+	Use:   "vote [feeder] [validator] [chain-id]",
+	Short: "generate an aggregate vote transaction revealing the last prevote, prevoting --exchange-rates when given",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		ob := oracleBody(args[0], args[1], args[2])
+		if voteExchangeRates != "" {
+			ob.ExchangeRates = parseExchangeRates(voteExchangeRates)
+		}
+		postTx("/tx/oracle/vote", ob.Marshal())
+	},
+}
+
+func oracleBody(feederArg, validatorArg, chainID string) api.OracleBody {
+	feeder, err := sdk.AccAddressFromBech32(feederArg)
+	if err != nil {
+		log.Fatalf("invalid feeder address %s", feederArg)
+	}
+	validator, err := sdk.ValAddressFromBech32(validatorArg)
+	if err != nil {
+		log.Fatalf("invalid validator address %s", validatorArg)
+	}
+
+	return api.OracleBody{
+		Feeder:     feeder,
+		Validator:  validator,
+		ChainID:    chainID,
+		Memo:       txMemo,
+		FeeOptions: txFeeOptions(),
+	}
+}
+
+func parseExchangeRates(arg string) map[string]string {
+	exchangeRates := make(map[string]string)
+	for _, tuple := range strings.Split(arg, ",") {
+		rate, err := sdk.ParseDecCoin(tuple)
+		if err != nil {
+			log.Fatalf("invalid exchange rate %s", tuple)
+		}
+		exchangeRates[rate.Denom] = rate.Amount.String()
+	}
+	return exchangeRates
+}
+
+func init() {
+	addFeeFlags(prevoteCmd)
+	addFeeFlags(voteCmd)
+	voteCmd.Flags().StringVar(&voteExchangeRates, "exchange-rates", "", "exchange rates to prevote in the same transaction, e.g. 8900.5ukrw,7.25uusd")
+	oracleCmd.AddCommand(prevoteCmd)
+	oracleCmd.AddCommand(voteCmd)
+	txCmd.AddCommand(oracleCmd)
+}
@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 
 	homedir "github.com/mitchellh/go-homedir"
@@ -89,4 +90,35 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		viper.Unmarshal(&server)
 	}
+
+	// kept out of the config file on purpose
+	server.KeyringPassphrase = os.Getenv("KEYSERVER_KEYRING_PASSPHRASE")
+	server.PKCS11PIN = os.Getenv("KEYSERVER_PKCS11_PIN")
+
+	// the commands authenticate with the api token against the keyserver of the config
+	if token := os.Getenv("KEYSERVER_API_TOKEN"); token != "" {
+		http.DefaultTransport = bearerTransport{
+			host:  fmt.Sprintf("localhost:%d", server.Port),
+			token: token,
+			next:  http.DefaultTransport,
+		}
+	}
+}
+
+// bearerTransport sets the bearer token on requests to the keyserver
+type bearerTransport struct {
+	host  string
+	token string
+	next  http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != t.host {
+		return t.next.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.next.RoundTrip(req)
 }
@@ -15,23 +15,113 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/handlers"
 	"github.com/spf13/cobra"
 )
 
+// defaultShutdownTimeout bounds the draining of in-flight requests when no shutdown timeout is configured,
+// raised to the broadcast timeout so waiting broadcasts can finish
+const defaultShutdownTimeout = 30 * time.Second
+
 // serveCmd represents the serve command
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Runs the server",
 	Run: func(cmd *cobra.Command, args []string) {
+		// the response of a block or commit-wait broadcast is written once the broadcast returns
+		if server.WriteTimeout != 0 && server.WriteTimeout <= server.BroadcastWait() {
+			log.Fatalf("writetimeout %s must be longer than the broadcast timeout %s", server.WriteTimeout, server.BroadcastWait())
+		}
+
+		if err := server.OpenKeybase(); err != nil {
+			log.Fatalf("failed to open keybase: %s", err)
+		}
+
+		srv := &http.Server{
+			Addr:         fmt.Sprintf(":%v", server.Port),
+			Handler:      handlers.LoggingHandler(os.Stdout, server.Router()),
+			ReadTimeout:  server.ReadTimeout,
+			WriteTimeout: server.WriteTimeout,
+			IdleTimeout:  server.IdleTimeout,
+		}
+
+		serve := func() error {
+			log.Println(fmt.Sprintf("Listening on port ':%v'...", server.Port))
+			return srv.ListenAndServe()
+		}
+
+		if server.TLSCert != "" {
+			tlsConfig, err := server.TLSConfig()
+			if err != nil {
+				server.Close()
+				log.Fatalf("failed to load tls config: %s", err)
+			}
+			srv.TLSConfig = tlsConfig
+
+			// reload the certificates on SIGHUP, connections keep being served
+			hups := make(chan os.Signal, 1)
+			signal.Notify(hups, syscall.SIGHUP)
+			go func() {
+				for range hups {
+					if err := server.ReloadTLS(); err != nil {
+						log.Printf("failed to reload tls certificates, keeping the current ones: %s", err)
+						continue
+					}
+					log.Println("reloaded tls certificates")
+				}
+			}()
+
+			serve = func() error {
+				log.Println(fmt.Sprintf("Listening on port ':%v' with tls...", server.Port))
+				return srv.ListenAndServeTLS("", "")
+			}
+		}
+
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+		errs := make(chan error, 1)
+		go func() { errs <- serve() }()
+
+		select {
+		case err := <-errs:
+			server.Close()
+			log.Fatal(err)
+		case sig := <-sigs:
+			log.Printf("received %s, draining in-flight requests...", sig)
+		}
+
+		// stop accepting connections and let the running sign and broadcast calls finish, so
+		// no key write or broadcast is cut off halfway
+		timeout := server.ShutdownTimeout
+		if timeout == 0 {
+			timeout = defaultShutdownTimeout
+			if timeout < server.BroadcastWait() {
+				timeout = server.BroadcastWait()
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("in-flight requests didn't finish within %s, closing their connections: %s", timeout, err)
+			srv.Close()
+		}
 
-		log.Println(fmt.Sprintf("Listening on port ':%v'...", server.Port))
-		log.Fatal(http.ListenAndServe(fmt.Sprintf(":%v", server.Port), handlers.LoggingHandler(os.Stdout, server.Router())))
+		if err := server.Close(); err != nil {
+			log.Printf("failed to close the keybase: %s", err)
+		}
+		log.Println("shut down")
 	},
 }
 
@@ -0,0 +1,144 @@
+// Copyright © 2018 Jack Zampolin <jack@blockstack.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/terra-project/keyserver/api"
+)
+
+var stakingCmd = &cobra.Command{
+	Use:   "staking",
+	Short: "staking transactions",
+}
+
+var distributionCmd = &cobra.Command{
+	Use:   "distribution",
+	Short: "distribution transactions",
+}
+
+var delegateCmd = &cobra.Command{
+	Use:   "delegate [delegator] [validator] [amount] [chain-id]",
+	Short: "generate a delegate transaction",
+	Args:  cobra.ExactArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		postTx("/tx/staking/delegate", delegateBody(args).Marshal())
+	},
+}
+
+var undelegateCmd = &cobra.Command{
+	Use:   "undelegate [delegator] [validator] [amount] [chain-id]",
+	Short: "generate an undelegate transaction",
+	Args:  cobra.ExactArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		postTx("/tx/staking/undelegate", delegateBody(args).Marshal())
+	},
+}
+
+var redelegateCmd = &cobra.Command{
+	Use:   "redelegate [delegator] [src-validator] [dst-validator] [amount] [chain-id]",
+	Short: "generate a redelegate transaction",
+	Args:  cobra.ExactArgs(5),
+	Run: func(cmd *cobra.Command, args []string) {
+		delegator, err := sdk.AccAddressFromBech32(args[0])
+		if err != nil {
+			log.Fatalf("invalid delegator address %s", args[0])
+		}
+		src, err := sdk.ValAddressFromBech32(args[1])
+		if err != nil {
+			log.Fatalf("invalid validator address %s", args[1])
+		}
+		dst, err := sdk.ValAddressFromBech32(args[2])
+		if err != nil {
+			log.Fatalf("invalid validator address %s", args[2])
+		}
+
+		rb := api.RedelegateBody{
+			Delegator:    delegator,
+			ValidatorSrc: src,
+			ValidatorDst: dst,
+			Amount:       args[3],
+			ChainID:      args[4],
+			Memo:         txMemo,
+			FeeOptions:   txFeeOptions(),
+		}
+		postTx("/tx/staking/redelegate", rb.Marshal())
+	},
+}
+
+var withdrawCmd = &cobra.Command{
+	Use:   "withdraw [delegator] [chain-id] [validator...]",
+	Short: "generate a withdraw rewards transaction, from every bonded validator when none is given",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		delegator, err := sdk.AccAddressFromBech32(args[0])
+		if err != nil {
+			log.Fatalf("invalid delegator address %s", args[0])
+		}
+
+		var validators []sdk.ValAddress
+		for _, arg := range args[2:] {
+			validator, err := sdk.ValAddressFromBech32(arg)
+			if err != nil {
+				log.Fatalf("invalid validator address %s", arg)
+			}
+			validators = append(validators, validator)
+		}
+
+		wb := api.WithdrawRewardsBody{
+			Delegator:  delegator,
+			Validators: validators,
+			ChainID:    args[1],
+			Memo:       txMemo,
+			FeeOptions: txFeeOptions(),
+		}
+		postTx("/tx/distribution/withdraw", wb.Marshal())
+	},
+}
+
+func delegateBody(args []string) api.DelegateBody {
+	delegator, err := sdk.AccAddressFromBech32(args[0])
+	if err != nil {
+		log.Fatalf("invalid delegator address %s", args[0])
+	}
+	validator, err := sdk.ValAddressFromBech32(args[1])
+	if err != nil {
+		log.Fatalf("invalid validator address %s", args[1])
+	}
+
+	return api.DelegateBody{
+		Delegator:  delegator,
+		Validator:  validator,
+		Amount:     args[2],
+		ChainID:    args[3],
+		Memo:       txMemo,
+		FeeOptions: txFeeOptions(),
+	}
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{delegateCmd, undelegateCmd, redelegateCmd} {
+		addFeeFlags(cmd)
+		stakingCmd.AddCommand(cmd)
+	}
+	addFeeFlags(withdrawCmd)
+	distributionCmd.AddCommand(withdrawCmd)
+
+	txCmd.AddCommand(stakingCmd)
+	txCmd.AddCommand(distributionCmd)
+}
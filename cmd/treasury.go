@@ -0,0 +1,52 @@
+// Copyright © 2018 Jack Zampolin <jack@blockstack.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/spf13/cobra"
+)
+
+var treasuryCmd = &cobra.Command{
+	Use:   "treasury",
+	Short: "show the tax rate and tax caps cached by the keyserver",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		url := fmt.Sprintf("http://localhost:%d/treasury", server.Port)
+		resp, err := http.Get(url)
+		if err != nil {
+			log.Fatalf("error fetching %s", url)
+			return
+		}
+		out, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			log.Fatalf("failed reading response body")
+			return
+		}
+		if resp.StatusCode != 200 {
+			log.Fatalf("non 200 respose code %d, error: %s", resp.StatusCode, string(out))
+			return
+		}
+		fmt.Println(string(out))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(treasuryCmd)
+}
@@ -36,6 +36,8 @@ var bankCmd = &cobra.Command{
 	Short: "bank transactions",
 }
 
+var broadcastMode string
+
 var broadcastCmd = &cobra.Command{
 	Use:   "broadcast [file]",
 	Short: "broadcast a signed transaction",
@@ -45,7 +47,7 @@ var broadcastCmd = &cobra.Command{
 		if err != nil {
 			log.Fatal("error reading transaction file")
 		}
-		url := fmt.Sprintf("http://localhost:%d/tx/broadcast", server.Port)
+		url := fmt.Sprintf("http://localhost:%d/tx/broadcast?mode=%s", server.Port, broadcastMode)
 		resp, err := http.Post(url, "application/json", bytes.NewBuffer(txData))
 		if err != nil {
 			log.Fatalf("error fetching %s", url)
@@ -64,6 +66,30 @@ var broadcastCmd = &cobra.Command{
 	},
 }
 
+var txQuery = &cobra.Command{
+	Use:   "query [hash]",
+	Short: "query the status of a transaction",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		url := fmt.Sprintf("http://localhost:%d/tx/%s", server.Port, args[0])
+		resp, err := http.Get(url)
+		if err != nil {
+			log.Fatalf("error fetching %s", url)
+			return
+		}
+		out, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			log.Fatalf("failed reading response body")
+			return
+		}
+		if resp.StatusCode != 200 {
+			log.Fatalf("non 200 respose code %d, error: %s", resp.StatusCode, string(out))
+			return
+		}
+		fmt.Println(string(out))
+	},
+}
+
 var encodeCmd = &cobra.Command{
 	Use:   "encode [file]",
 	Short: "encode a signed transaction",
@@ -173,21 +199,29 @@ var sendCmd = &cobra.Command{
 // /keys GET
 var txSign = &cobra.Command{
 	Use:   "sign [name] [password] [chain-id] [account-number] [sequence] [tx-file]",
-	Args:  cobra.ExactArgs(6),
-	Short: "Sign a transaction",
+	Short: "Sign a transaction, account-number and sequence are loaded from the node when omitted",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 4 && len(args) != 6 {
+			return fmt.Errorf("accepts 4 or 6 arg(s), received %d", len(args))
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		txData, err := ioutil.ReadFile(args[5])
+		txData, err := ioutil.ReadFile(args[len(args)-1])
 		if err != nil {
 			log.Fatal("error reading transaction file")
 		}
 
 		postData := api.SignBody{
-			Name:          args[0],
-			Passphrase:    args[1],
-			ChainID:       args[2],
-			AccountNumber: args[3],
-			Sequence:      args[4],
-			Tx:            txData,
+			Name:       args[0],
+			Passphrase: args[1],
+			ChainID:    args[2],
+			Tx:         txData,
+		}
+
+		if len(args) == 6 {
+			postData.AccountNumber = args[3]
+			postData.Sequence = args[4]
 		}
 
 		url := fmt.Sprintf("http://localhost:%d/tx/sign", server.Port)
@@ -209,11 +243,142 @@ var txSign = &cobra.Command{
 	},
 }
 
+var txSubmit = &cobra.Command{
+	Use:   "submit [name] [password] [chain-id] [tx-file]",
+	Args:  cobra.ExactArgs(4),
+	Short: "Sign and broadcast a transaction",
+	Run: func(cmd *cobra.Command, args []string) {
+		txData, err := ioutil.ReadFile(args[3])
+		if err != nil {
+			log.Fatal("error reading transaction file")
+		}
+
+		postData := api.SubmitBody{
+			Name:       args[0],
+			Passphrase: args[1],
+			ChainID:    args[2],
+			Tx:         txData,
+		}
+
+		url := fmt.Sprintf("http://localhost:%d/tx/submit", server.Port)
+		resp, err := http.Post(url, "application/json", bytes.NewBuffer(postData.Marshal()))
+		if err != nil {
+			log.Fatalf("error fetching %s", url)
+			return
+		}
+		out, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			log.Fatalf("failed reading response body")
+			return
+		}
+		if resp.StatusCode != 200 {
+			log.Fatalf("non 200 respose code %d, error: %s", resp.StatusCode, string(out))
+			return
+		}
+		fmt.Println(string(out))
+	},
+}
+
+var txCompose = &cobra.Command{
+	Use:   "compose [msgs-file] [chain-id]",
+	Short: "generate a transaction out of the amino JSON array of msgs in msgs-file",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		msgs, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			log.Fatal("error reading msgs file")
+		}
+
+		cb := api.ComposeBody{
+			Msgs:       msgs,
+			ChainID:    args[1],
+			Memo:       txMemo,
+			FeeOptions: txFeeOptions(),
+		}
+		postTx("/tx/compose", cb.Marshal())
+	},
+}
+
+var estimateMsgs bool
+
+var txEstimate = &cobra.Command{
+	Use:   "estimate [file]",
+	Short: "estimate the gas, tax and fee of an unsigned transaction, or of the amino JSON array of msgs in file with --msgs",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			log.Fatal("error reading transaction file")
+		}
+
+		eb := api.EstimateBody{
+			Memo:       txMemo,
+			FeeOptions: txFeeOptions(),
+		}
+		if estimateMsgs {
+			eb.Msgs = data
+		} else {
+			eb.Tx = data
+		}
+		postTx("/tx/estimate", eb.Marshal())
+	},
+}
+
+// fee flags shared by the transaction generating commands
+var (
+	txMemo          string
+	txFees          string
+	txGas           string
+	txGasPrices     string
+	txGasAdjustment string
+)
+
+// txFeeOptions returns the fee options of the fee flags
+func txFeeOptions() api.FeeOptions {
+	return api.FeeOptions{Fees: txFees, Gas: txGas, GasPrices: txGasPrices, GasAdjustment: txGasAdjustment}
+}
+
+func addFeeFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&txMemo, "memo", "", "memo to send along with the transaction")
+	cmd.Flags().StringVar(&txFees, "fees", "", "fees to pay along with the transaction, e.g. 10uluna")
+	cmd.Flags().StringVar(&txGas, "gas", "", "gas limit, simulated when empty")
+	cmd.Flags().StringVar(&txGasPrices, "gas-prices", "", "gas prices to determine the transaction fee, e.g. 0.015uluna")
+	cmd.Flags().StringVar(&txGasAdjustment, "gas-adjustment", "", "adjustment factor multiplied against the simulated gas")
+}
+
+// postTx posts a transaction request to the keyserver route and prints the response
+func postTx(route string, data []byte) {
+	url := fmt.Sprintf("http://localhost:%d%s", server.Port, route)
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		log.Fatalf("error fetching %s", url)
+		return
+	}
+	out, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatalf("failed reading response body")
+		return
+	}
+	if resp.StatusCode != 200 {
+		log.Fatalf("non 200 respose code %d, error: %s", resp.StatusCode, string(out))
+		return
+	}
+	fmt.Println(string(out))
+}
+
 func init() {
 	txCmd.AddCommand(txSign)
+	txCmd.AddCommand(txSubmit)
+	txCmd.AddCommand(txCompose)
+	addFeeFlags(txCompose)
+	txCmd.AddCommand(txEstimate)
+	addFeeFlags(txEstimate)
+	txEstimate.Flags().BoolVar(&estimateMsgs, "msgs", false, "read file as an amino JSON array of msgs instead of a transaction")
 	txCmd.AddCommand(bankCmd)
 	txCmd.AddCommand(broadcastCmd)
+	broadcastCmd.Flags().StringVar(&broadcastMode, "mode", api.BroadcastSync, "broadcast mode (sync|async|block|commit-wait)")
 	txCmd.AddCommand(encodeCmd)
+	txCmd.AddCommand(txQuery)
 	bankCmd.AddCommand(sendCmd)
 	rootCmd.AddCommand(txCmd)
 }
@@ -0,0 +1,132 @@
+// Copyright © 2018 Jack Zampolin <jack@blockstack.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/spf13/cobra"
+	"github.com/terra-project/keyserver/api"
+)
+
+var (
+	wasmCoins      string
+	wasmMigratable bool
+)
+
+var wasmCmd = &cobra.Command{
+	Use:   "wasm",
+	Short: "wasm transactions",
+}
+
+var wasmStoreCmd = &cobra.Command{
+	Use:   "store [sender] [wasm-file] [chain-id]",
+	Short: "generate a transaction uploading the wasm byte code in wasm-file",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		sender, err := sdk.AccAddressFromBech32(args[0])
+		if err != nil {
+			log.Fatalf("invalid sender address %s", args[0])
+		}
+
+		byteCode, err := ioutil.ReadFile(args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		sb := api.WasmStoreBody{
+			Sender:       sender,
+			WASMByteCode: byteCode,
+			ChainID:      args[2],
+			Memo:         txMemo,
+			FeeOptions:   txFeeOptions(),
+		}
+		postTx("/tx/wasm/store", sb.Marshal())
+	},
+}
+
+var wasmInstantiateCmd = &cobra.Command{
+	Use:   "instantiate [owner] [code-id] [init-msg] [chain-id]",
+	Short: "generate a transaction instantiating a contract from uploaded code with the JSON init-msg",
+	Args:  cobra.ExactArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		owner, err := sdk.AccAddressFromBech32(args[0])
+		if err != nil {
+			log.Fatalf("invalid owner address %s", args[0])
+		}
+
+		if !json.Valid([]byte(args[2])) {
+			log.Fatalf("init-msg %s is not valid JSON", args[2])
+		}
+
+		ib := api.WasmInstantiateBody{
+			Owner:      owner,
+			CodeID:     args[1],
+			InitMsg:    json.RawMessage(args[2]),
+			InitCoins:  wasmCoins,
+			Migratable: wasmMigratable,
+			ChainID:    args[3],
+			Memo:       txMemo,
+			FeeOptions: txFeeOptions(),
+		}
+		postTx("/tx/wasm/instantiate", ib.Marshal())
+	},
+}
+
+var wasmExecuteCmd = &cobra.Command{
+	Use:   "execute [sender] [contract] [execute-msg] [chain-id]",
+	Short: "generate a transaction executing a contract with the JSON execute-msg",
+	Args:  cobra.ExactArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		sender, err := sdk.AccAddressFromBech32(args[0])
+		if err != nil {
+			log.Fatalf("invalid sender address %s", args[0])
+		}
+
+		contract, err := sdk.AccAddressFromBech32(args[1])
+		if err != nil {
+			log.Fatalf("invalid contract address %s", args[1])
+		}
+
+		if !json.Valid([]byte(args[2])) {
+			log.Fatalf("execute-msg %s is not valid JSON", args[2])
+		}
+
+		eb := api.WasmExecuteBody{
+			Sender:     sender,
+			Contract:   contract,
+			ExecuteMsg: json.RawMessage(args[2]),
+			Coins:      wasmCoins,
+			ChainID:    args[3],
+			Memo:       txMemo,
+			FeeOptions: txFeeOptions(),
+		}
+		postTx("/tx/wasm/execute", eb.Marshal())
+	},
+}
+
+func init() {
+	addFeeFlags(wasmStoreCmd)
+	addFeeFlags(wasmInstantiateCmd)
+	addFeeFlags(wasmExecuteCmd)
+	wasmInstantiateCmd.Flags().StringVar(&wasmCoins, "coins", "", "coins to send to the contract on instantiation")
+	wasmInstantiateCmd.Flags().BoolVar(&wasmMigratable, "migratable", false, "allow the owner to migrate the contract")
+	wasmExecuteCmd.Flags().StringVar(&wasmCoins, "coins", "", "coins to send to the contract with the execution")
+	wasmCmd.AddCommand(wasmStoreCmd, wasmInstantiateCmd, wasmExecuteCmd)
+	txCmd.AddCommand(wasmCmd)
+}